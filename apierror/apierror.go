@@ -0,0 +1,42 @@
+package apierror
+
+import (
+	"fmt"
+)
+
+// Codes clients can rely on, sent as extensions.code on GraphQL errors.
+const (
+	NotFound            = "NOT_FOUND"
+	Unauthenticated     = "UNAUTHENTICATED"
+	Forbidden           = "FORBIDDEN"
+	InvalidArgument     = "INVALID_ARGUMENT"
+	QueryTooComplex     = "QUERY_TOO_COMPLEX"
+	RateLimited         = "RATE_LIMITED"
+	UpstreamUnavailable = "UPSTREAM_UNAVAILABLE"
+	Internal            = "INTERNAL"
+)
+
+// Error is an error meant for API clients: its message is safe to show them
+// as is. Errors that aren't one of these are logged and replaced with a
+// generic message.
+type Error struct {
+	Code    string
+	Message string
+}
+
+func New(code string, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func Errorf(code string, format string, args ...interface{}) *Error {
+	return New(code, fmt.Sprintf(format, args...))
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Extensions is picked up by gqlgen when the error is presented.
+func (e *Error) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.Code}
+}
@@ -0,0 +1,63 @@
+package changes
+
+import (
+	"context"
+	"github.com/andrewmthomas87/northwestern/models"
+	"sync"
+)
+
+// subscriberBuffer is how many changes a subscriber can fall behind by before
+// changes are dropped for it rather than holding up everyone else.
+const subscriberBuffer = 64
+
+type subscriber struct {
+	filter  func(*models.CourseChange) bool
+	changes chan *models.CourseChange
+}
+
+// Broker fans course changes out to subscribers within this process.
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[*subscriber]bool
+}
+
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[*subscriber]bool)}
+}
+
+// Subscribe delivers changes that pass filter until ctx is done, when the
+// channel is closed.
+func (b *Broker) Subscribe(ctx context.Context, filter func(*models.CourseChange) bool) <-chan *models.CourseChange {
+	s := &subscriber{filter: filter, changes: make(chan *models.CourseChange, subscriberBuffer)}
+
+	b.mu.Lock()
+	b.subscribers[s] = true
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		b.mu.Lock()
+		delete(b.subscribers, s)
+		close(s.changes)
+		b.mu.Unlock()
+	}()
+
+	return s.changes
+}
+
+func (b *Broker) Publish(change *models.CourseChange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for s := range b.subscribers {
+		if !s.filter(change) {
+			continue
+		}
+
+		select {
+		case s.changes <- change:
+		default:
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package changes
+
+import (
+	"github.com/andrewmthomas87/northwestern/models"
+)
+
+var courseFields = []struct {
+	name  string
+	value func(*models.Course) interface{}
+}{
+	{"title", func(c *models.Course) interface{} { return c.Title }},
+	{"school", func(c *models.Course) interface{} { return c.School }},
+	{"instructor", func(c *models.Course) interface{} { return c.Instructor }},
+	{"subject", func(c *models.Course) interface{} { return c.Subject }},
+	{"catalogNum", func(c *models.Course) interface{} { return c.CatalogNum }},
+	{"section", func(c *models.Course) interface{} { return c.Section }},
+	{"room", func(c *models.Course) interface{} { return c.Room }},
+	{"meetingDays", func(c *models.Course) interface{} { return c.MeetingDays }},
+	{"startTime", func(c *models.Course) interface{} { return c.StartTime }},
+	{"endTime", func(c *models.Course) interface{} { return c.EndTime }},
+	{"startDate", func(c *models.Course) interface{} { return c.StartDate }},
+	{"endDate", func(c *models.Course) interface{} { return c.EndDate }},
+	{"seats", func(c *models.Course) interface{} { return c.Seats }},
+	{"overview", func(c *models.Course) interface{} { return c.Overview }},
+	{"topic", func(c *models.Course) interface{} { return c.Topic }},
+	{"attributes", func(c *models.Course) interface{} { return c.Attributes }},
+	{"requirements", func(c *models.Course) interface{} { return c.Requirements }},
+	{"component", func(c *models.Course) interface{} { return c.Component }},
+	{"classNum", func(c *models.Course) interface{} { return c.ClassNum }},
+	{"courseId", func(c *models.Course) interface{} { return c.CourseId }},
+}
+
+// Detect compares a term's courses before and after a scrape.
+func Detect(previous []*models.Course, current []*models.Course) []*models.CourseChange {
+	previousById := make(map[int]*models.Course, len(previous))
+	for _, course := range previous {
+		previousById[course.Id] = course
+	}
+
+	var changes []*models.CourseChange
+	currentIds := make(map[int]bool, len(current))
+	for _, course := range current {
+		currentIds[course.Id] = true
+	}
+	for _, course := range previous {
+		if !currentIds[course.Id] {
+			changes = append(changes, &models.CourseChange{
+				Course:        course.Id,
+				Term:          course.Term,
+				Subject:       course.Subject,
+				Kind:          models.CourseRemoved,
+				PreviousSeats: course.Seats,
+			})
+		}
+	}
+
+	for _, course := range current {
+		change := &models.CourseChange{
+			Course:  course.Id,
+			Term:    course.Term,
+			Subject: course.Subject,
+			Seats:   course.Seats,
+		}
+
+		old, ok := previousById[course.Id]
+		if !ok {
+			change.Kind = models.CourseAdded
+			changes = append(changes, change)
+			continue
+		}
+
+		for _, field := range courseFields {
+			if field.value(old) != field.value(course) {
+				change.Fields = append(change.Fields, field.name)
+			}
+		}
+		if len(change.Fields) > 0 {
+			change.Kind = models.CourseUpdated
+			change.PreviousSeats = old.Seats
+			changes = append(changes, change)
+		}
+	}
+
+	return changes
+}
+
+func SeatsChanged(change *models.CourseChange) bool {
+	return change.Kind == models.CourseUpdated && change.Seats != change.PreviousSeats
+}
@@ -0,0 +1,51 @@
+package changes
+
+import (
+	"context"
+	"github.com/andrewmthomas87/northwestern/models"
+	"log"
+	"time"
+)
+
+const pollBatchSize = 1000
+
+type Store interface {
+	SelectLastCourseChangeID(ctx context.Context) (int, error)
+	SelectCourseChangesAfter(ctx context.Context, id int, limit int) ([]*models.CourseChange, error)
+}
+
+// Poll publishes changes the scraper records, which runs in its own process,
+// as they show up. Only changes recorded after Poll starts are published.
+func Poll(ctx context.Context, store Store, broker *Broker, interval time.Duration) error {
+	last, err := store.SelectLastCourseChangeID(ctx)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		for {
+			changes, err := store.SelectCourseChangesAfter(ctx, last, pollBatchSize)
+			if err != nil {
+				log.Println(err)
+				break
+			}
+
+			for _, change := range changes {
+				broker.Publish(change)
+				last = change.ID
+			}
+			if len(changes) < pollBatchSize {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package northwestern
+
+import (
+	"github.com/andrewmthomas87/northwestern/generated"
+	"github.com/andrewmthomas87/northwestern/models"
+)
+
+// Rough row counts for lists that aren't paginated, used to weight what is
+// selected inside them.
+const (
+	schoolsListSize      = 15
+	subjectsListSize     = 200
+	roomsListSize        = 30
+	freeRoomsListSize    = 200
+	attributesListSize   = 100
+	roomScheduleListSize = 50
+	courseAttributesSize = 5
+	componentsListSize   = 3
+	adminListSize        = 100
+	watchedListSize      = 20
+)
+
+// clampSize keeps client supplied sizes from overflowing the estimate. Pages
+// past maxPageSize are rejected when they run anyway, and limits are capped.
+func clampSize(n int) int {
+	if n < 0 {
+		return 0
+	}
+	if n > maxPageSize {
+		return maxPageSize
+	}
+	return n
+}
+
+func pageSize(first *int, last *int) int {
+	switch {
+	case first != nil:
+		return clampSize(*first)
+	case last != nil:
+		return clampSize(*last)
+	}
+	return defaultPageSize
+}
+
+func listComplexity(size int) func(int) int {
+	return func(childComplexity int) int {
+		return size * childComplexity
+	}
+}
+
+func pageComplexity(childComplexity int, first *int, after *string, last *int, before *string) int {
+	return pageSize(first, last) * childComplexity
+}
+
+// NewComplexityRoot weights each list field's selection by how many rows it
+// can return, taken from its pagination arguments where it has them. The cost
+// of the field itself is added on top by querycost.
+func NewComplexityRoot() generated.ComplexityRoot {
+	var c generated.ComplexityRoot
+
+	c.Query.Terms = pageComplexity
+	c.Query.Subjects = pageComplexity
+	c.Query.Buildings = pageComplexity
+	c.Query.Rooms = pageComplexity
+	c.Query.Courses = func(childComplexity int, term int, subject *string, attribute *string, first *int, after *string, last *int, before *string) int {
+		return pageComplexity(childComplexity, first, after, last, before)
+	}
+	c.Query.CoursesByAttribute = func(childComplexity int, term int, attribute string, first *int, after *string, last *int, before *string) int {
+		return pageComplexity(childComplexity, first, after, last, before)
+	}
+	c.Query.Schools = listComplexity(schoolsListSize)
+	c.Query.SubjectsByTerm = func(childComplexity int, term int) int {
+		return subjectsListSize * childComplexity
+	}
+	c.Query.RoomsByBuilding = func(childComplexity int, building int) int {
+		return roomsListSize * childComplexity
+	}
+	c.Query.FreeRooms = func(childComplexity int, term int, day string, start models.Time, end models.Time, building *int, near *models.LocationInput) int {
+		return freeRoomsListSize * childComplexity
+	}
+	c.Query.Attributes = listComplexity(attributesListSize)
+	c.Query.Schedule = func(childComplexity int, courses []int, tightTransitionsAsConflicts *bool) int {
+		return len(courses) * childComplexity
+	}
+	c.Query.Nodes = func(childComplexity int, ids []string) int {
+		return len(ids) * childComplexity
+	}
+	c.Query.WatchedCourses = listComplexity(watchedListSize)
+	c.Query.Users = listComplexity(adminListSize)
+	c.Query.ScrapeRequests = func(childComplexity int, limit *int) int {
+		if limit != nil {
+			return clampSize(*limit) * childComplexity
+		}
+		return defaultScrapeRequestsLimit * childComplexity
+	}
+	c.Query.DataCorrections = listComplexity(adminListSize)
+	c.Query.Webhooks = listComplexity(adminListSize)
+	c.Query.WebhookDeliveries = func(childComplexity int, webhook *int, status *models.WebhookDeliveryStatus, limit *int) int {
+		if limit != nil {
+			return clampSize(*limit) * childComplexity
+		}
+		return defaultWebhookDeliveriesLimit * childComplexity
+	}
+
+	c.Building.Rooms = listComplexity(roomsListSize)
+	c.Building.Courses = func(childComplexity int, term int, first *int, after *string, last *int, before *string) int {
+		return pageComplexity(childComplexity, first, after, last, before)
+	}
+	c.Room.Schedule = func(childComplexity int, term int) int {
+		return roomScheduleListSize * childComplexity
+	}
+	c.Course.Attributes = listComplexity(courseAttributesSize)
+	c.Course.Components = listComplexity(componentsListSize)
+
+	return c
+}
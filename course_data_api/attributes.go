@@ -0,0 +1,52 @@
+package course_data_api
+
+import (
+	"github.com/andrewmthomas87/northwestern/models"
+	"regexp"
+	"strings"
+)
+
+const (
+	AttributeCategoryDistro = "DISTRO"
+	AttributeCategoryOther  = "OTHER"
+)
+
+var attributeSeparator = regexp.MustCompile(`[\n;,]+`)
+var attributeSymbolInvalid = regexp.MustCompile(`[^A-Z0-9]+`)
+
+func ParseAttributes(raw string) []*models.Attribute {
+	var attributes []*models.Attribute
+	seen := make(map[string]bool)
+	for _, name := range attributeSeparator.Split(raw, -1) {
+		name = strings.Join(strings.Fields(name), " ")
+		if len(name) == 0 {
+			continue
+		}
+
+		symbol := attributeSymbol(name)
+		if len(symbol) == 0 || seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+
+		attributes = append(attributes, &models.Attribute{
+			Symbol:   symbol,
+			Name:     name,
+			Category: attributeCategory(name),
+		})
+	}
+
+	return attributes
+}
+
+func attributeSymbol(name string) string {
+	return strings.Trim(attributeSymbolInvalid.ReplaceAllString(strings.ToUpper(name), "_"), "_")
+}
+
+func attributeCategory(name string) string {
+	if strings.Contains(strings.ToLower(name), "distro area") {
+		return AttributeCategoryDistro
+	}
+
+	return AttributeCategoryOther
+}
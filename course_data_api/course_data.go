@@ -64,33 +64,41 @@ type apiTerm struct {
 	EndDate   string `json:"end_date"`
 }
 
-func (c *Client) Terms() ([]*models.Term, error) {
+func (c *Client) Terms() ([]*models.Term, []*InvalidValue, error) {
 	req, err := c.newRequest("terms", nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	body, err := c.doRequest(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var apiTerms []apiTerm
 	if err := json.Unmarshal(body, &apiTerms); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	terms := make([]*models.Term, len(apiTerms))
-	for i, apiTerm := range apiTerms {
-		terms[i] = &models.Term{
+	terms := make([]*models.Term, 0, len(apiTerms))
+	var invalid []*InvalidValue
+	for _, apiTerm := range apiTerms {
+		v := &values{entity: "term", id: apiTerm.Id}
+		term := &models.Term{
 			Id:        apiTerm.Id,
 			Name:      apiTerm.Name,
-			StartDate: apiTerm.StartDate,
-			EndDate:   apiTerm.EndDate,
+			StartDate: v.requiredDate("startDate", apiTerm.StartDate),
+			EndDate:   v.requiredDate("endDate", apiTerm.EndDate),
 		}
+		if len(v.invalid) > 0 {
+			invalid = append(invalid, v.invalid...)
+			continue
+		}
+
+		terms = append(terms, term)
 	}
 
-	return terms, nil
+	return terms, invalid, nil
 }
 
 type apiSchool struct {
@@ -321,7 +329,7 @@ type apiCourse struct {
 	} `json:"course_components"`
 }
 
-func (c *Client) Courses(term int, subject string, instructors map[string]int) ([]*models.Course, []*models.CourseDescription, []*models.CourseComponent, error) {
+func (c *Client) Courses(term int, subject string, instructors map[string]int) ([]*models.Course, []*models.CourseDescription, []*models.CourseComponent, []*InvalidValue, error) {
 	parameters := []string{
 		fmt.Sprintf("term=%d", term),
 		fmt.Sprintf("subject=%s", subject),
@@ -329,23 +337,25 @@ func (c *Client) Courses(term int, subject string, instructors map[string]int) (
 
 	req, err := c.newRequest("courses/details", parameters)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
 	body, err := c.doRequest(req)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
 	var apiCourses []apiCourse
 	if err := json.Unmarshal(body, &apiCourses); err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
 	courses := make([]*models.Course, len(apiCourses))
 	courseDescriptions := make([]*models.CourseDescription, 0)
 	courseComponents := make([]*models.CourseComponent, 0)
+	var invalid []*InvalidValue
 	for i, apiCourse := range apiCourses {
+		v := &values{entity: "course", id: apiCourse.Id}
 		courses[i] = &models.Course{
 			Id:           apiCourse.Id,
 			Title:        apiCourse.Title,
@@ -357,10 +367,10 @@ func (c *Client) Courses(term int, subject string, instructors map[string]int) (
 			Section:      apiCourse.Section,
 			Room:         apiCourse.Room.Id,
 			MeetingDays:  apiCourse.MeetingDays,
-			StartTime:    apiCourse.StartTime,
-			EndTime:      apiCourse.EndTime,
-			StartDate:    apiCourse.StartDate,
-			EndDate:      apiCourse.EndDate,
+			StartTime:    v.time("startTime", apiCourse.StartTime),
+			EndTime:      v.time("endTime", apiCourse.EndTime),
+			StartDate:    v.date("startDate", apiCourse.StartDate),
+			EndDate:      v.date("endDate", apiCourse.EndDate),
 			Seats:        apiCourse.Seats,
 			Overview:     apiCourse.Overview,
 			Topic:        apiCourse.Topic,
@@ -383,13 +393,14 @@ func (c *Client) Courses(term int, subject string, instructors map[string]int) (
 				Course:      apiCourse.Id,
 				Component:   apiCourseComponent.Component,
 				MeetingDays: apiCourseComponent.MeetingDays,
-				StartTime:   apiCourseComponent.StartTime,
-				EndTime:     apiCourseComponent.EndTime,
+				StartTime:   v.time("components.startTime", apiCourseComponent.StartTime),
+				EndTime:     v.time("components.endTime", apiCourseComponent.EndTime),
 				Section:     apiCourseComponent.Section,
 				Room:        apiCourseComponent.Room,
 			})
 		}
+		invalid = append(invalid, v.invalid...)
 	}
 
-	return courses, courseDescriptions, courseComponents, nil
+	return courses, courseDescriptions, courseComponents, invalid, nil
 }
@@ -0,0 +1,56 @@
+package course_data_api
+
+import (
+	"fmt"
+	"github.com/andrewmthomas87/northwestern/models"
+)
+
+// InvalidValue is a date or time upstream sent that doesn't parse. Courses are
+// kept without the value; terms can't be used without their dates and are
+// left out.
+type InvalidValue struct {
+	Entity string
+	Id     int
+	Field  string
+	Value  string
+}
+
+func (v *InvalidValue) String() string {
+	return fmt.Sprintf("%s %d has an invalid %s: %q", v.Entity, v.Id, v.Field, v.Value)
+}
+
+// values parses the dates and times of one record, collecting what doesn't
+// parse.
+type values struct {
+	entity  string
+	id      int
+	invalid []*InvalidValue
+}
+
+func (v *values) flag(field string, value string) {
+	v.invalid = append(v.invalid, &InvalidValue{Entity: v.entity, Id: v.id, Field: field, Value: value})
+}
+
+func (v *values) date(field string, value string) models.Date {
+	date, err := models.ParseDate(value)
+	if err != nil {
+		v.flag(field, value)
+	}
+	return date
+}
+
+func (v *values) requiredDate(field string, value string) models.Date {
+	date, err := models.ParseDate(value)
+	if err != nil || date.IsZero() {
+		v.flag(field, value)
+	}
+	return date
+}
+
+func (v *values) time(field string, value string) models.Time {
+	t, err := models.ParseTime(value)
+	if err != nil {
+		v.flag(field, value)
+	}
+	return t
+}
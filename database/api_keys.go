@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"github.com/andrewmthomas87/northwestern/models"
+)
+
+const apiKeyColumns = "id, name, prefix, scope, created_at, expires_at, last_used_at, revoked"
+
+func scanAPIKey(row interface{ Scan(...interface{}) error }) (*models.APIKey, error) {
+	apiKey := &models.APIKey{}
+	if err := row.Scan(&apiKey.ID, &apiKey.Name, &apiKey.Prefix, &apiKey.Scope, &apiKey.CreatedAt, &apiKey.ExpiresAt, &apiKey.LastUsedAt, &apiKey.Revoked); err != nil {
+		return nil, err
+	}
+
+	return apiKey, nil
+}
+
+func (d *Database) InsertAPIKey(ctx context.Context, email string, name string, prefix string, keyHash string, scope models.APIKeyScope, expiresInDays *int) (*models.APIKey, error) {
+	result, err := d.db.ExecContext(ctx, "INSERT INTO api_keys (email, name, prefix, key_hash, scope, created_at, expires_at) VALUES (?, ?, ?, ?, ?, UTC_TIMESTAMP(), IF(? IS NULL, NULL, UTC_TIMESTAMP() + INTERVAL ? DAY))", email, name, prefix, keyHash, scope, expiresInDays, expiresInDays)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAPIKey(d.db.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE id=?", id))
+}
+
+func (d *Database) SelectAPIKeysByEmail(ctx context.Context, email string) ([]*models.APIKey, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE email=? ORDER BY id", email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var apiKeys []*models.APIKey
+	for rows.Next() {
+		apiKey, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	return apiKeys, nil
+}
+
+func (d *Database) RevokeAPIKey(ctx context.Context, email string, id int) (bool, error) {
+	result, err := d.db.ExecContext(ctx, "UPDATE api_keys SET revoked=TRUE WHERE id=? AND email=?", id, email)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// AuthenticateAPIKey finds an active key by its hash. Last use is recorded at
+// most once a minute per key to keep authentication from writing on every
+// request.
+func (d *Database) AuthenticateAPIKey(ctx context.Context, keyHash string) (string, string, bool, error) {
+	var id int
+	var email, scope string
+	row := d.db.QueryRowContext(ctx, "SELECT id, email, scope FROM api_keys WHERE key_hash=? AND NOT revoked AND (expires_at IS NULL OR expires_at > UTC_TIMESTAMP())", keyHash)
+	if err := row.Scan(&id, &email, &scope); err != nil {
+		if err == sql.ErrNoRows {
+			return "", "", false, nil
+		}
+		return "", "", false, err
+	}
+
+	if _, err := d.db.ExecContext(ctx, "UPDATE api_keys SET last_used_at=UTC_TIMESTAMP() WHERE id=? AND (last_used_at IS NULL OR last_used_at < UTC_TIMESTAMP() - INTERVAL 1 MINUTE)", id); err != nil {
+		return "", "", false, err
+	}
+
+	return email, scope, true, nil
+}
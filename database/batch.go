@@ -0,0 +1,240 @@
+package database
+
+import (
+	"context"
+	"github.com/andrewmthomas87/northwestern/models"
+	"strings"
+)
+
+func inPlaceholders(n int) string {
+	return "(" + strings.TrimSuffix(strings.Repeat("?, ", n), ", ") + ")"
+}
+
+func intArgs(ids []int) []interface{} {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	return args
+}
+
+func (d *Database) SelectTermsByIds(ctx context.Context, ids []int) ([]*models.Term, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	rows, err := d.db.QueryContext(ctx, "SELECT id, name, start_date, end_date FROM terms WHERE id IN "+inPlaceholders(len(ids)), intArgs(ids)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var terms []*models.Term
+	for rows.Next() {
+		term := &models.Term{}
+		if err := rows.Scan(&term.Id, &term.Name, &term.StartDate, &term.EndDate); err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+
+	return terms, nil
+}
+
+func stringArgs(values []string) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+
+	return args
+}
+
+func (d *Database) SelectSchoolsBySymbols(ctx context.Context, symbols []string) ([]*models.School, error) {
+	if len(symbols) == 0 {
+		return nil, nil
+	}
+
+	rows, err := d.db.QueryContext(ctx, "SELECT symbol, name FROM schools WHERE symbol IN "+inPlaceholders(len(symbols)), stringArgs(symbols)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schools []*models.School
+	for rows.Next() {
+		school := &models.School{}
+		if err := rows.Scan(&school.Symbol, &school.Name); err != nil {
+			return nil, err
+		}
+		schools = append(schools, school)
+	}
+
+	return schools, nil
+}
+
+func (d *Database) SelectSubjectsBySymbols(ctx context.Context, symbols []string) ([]*models.Subject, error) {
+	if len(symbols) == 0 {
+		return nil, nil
+	}
+
+	rows, err := d.db.QueryContext(ctx, "SELECT symbol, name FROM subjects WHERE symbol IN "+inPlaceholders(len(symbols)), stringArgs(symbols)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subjects []*models.Subject
+	for rows.Next() {
+		subject := &models.Subject{}
+		if err := rows.Scan(&subject.Symbol, &subject.Name); err != nil {
+			return nil, err
+		}
+		subjects = append(subjects, subject)
+	}
+
+	return subjects, nil
+}
+
+func (d *Database) SelectInstructorsByIds(ctx context.Context, ids []int) ([]*models.Instructor, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	rows, err := d.db.QueryContext(ctx, "SELECT id, name, phone FROM instructors WHERE id IN "+inPlaceholders(len(ids)), intArgs(ids)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var instructors []*models.Instructor
+	for rows.Next() {
+		instructor := &models.Instructor{}
+		if err := rows.Scan(&instructor.Id, &instructor.Name, &instructor.Phone); err != nil {
+			return nil, err
+		}
+		instructors = append(instructors, instructor)
+	}
+
+	return instructors, nil
+}
+
+func (d *Database) SelectRoomsByBuildings(ctx context.Context, buildings []int) ([]*models.Room, error) {
+	if len(buildings) == 0 {
+		return nil, nil
+	}
+
+	rows, err := d.db.QueryContext(ctx, "SELECT id, building_id, name FROM rooms WHERE building_id IN "+inPlaceholders(len(buildings)), intArgs(buildings)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rooms []*models.Room
+	for rows.Next() {
+		room := &models.Room{}
+		if err := rows.Scan(&room.Id, &room.BuildingId, &room.Name); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, room)
+	}
+
+	return rooms, nil
+}
+
+func (d *Database) SelectCourseComponentsByCourses(ctx context.Context, courses []int) ([]*models.CourseComponent, error) {
+	if len(courses) == 0 {
+		return nil, nil
+	}
+
+	rows, err := d.db.QueryContext(ctx, "SELECT course, component, meeting_days, start_time, end_time, section, room FROM course_components WHERE course IN "+inPlaceholders(len(courses)), intArgs(courses)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var courseComponents []*models.CourseComponent
+	for rows.Next() {
+		courseComponent := &models.CourseComponent{}
+		if err := rows.Scan(&courseComponent.Course, &courseComponent.Component, &courseComponent.MeetingDays, &courseComponent.StartTime, &courseComponent.EndTime, &courseComponent.Section, &courseComponent.Room); err != nil {
+			return nil, err
+		}
+		courseComponents = append(courseComponents, courseComponent)
+	}
+
+	return courseComponents, nil
+}
+
+func (d *Database) SelectCourseAttributesByCourses(ctx context.Context, courses []int) (map[int][]*models.Attribute, error) {
+	if len(courses) == 0 {
+		return nil, nil
+	}
+
+	rows, err := d.db.QueryContext(ctx, "SELECT course, symbol, name, category FROM attributes, course_attributes WHERE symbol=attribute AND course IN "+inPlaceholders(len(courses)), intArgs(courses)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attributes := make(map[int][]*models.Attribute)
+	for rows.Next() {
+		var course int
+		attribute := &models.Attribute{}
+		if err := rows.Scan(&course, &attribute.Symbol, &attribute.Name, &attribute.Category); err != nil {
+			return nil, err
+		}
+		attributes[course] = append(attributes[course], attribute)
+	}
+
+	return attributes, nil
+}
+
+func (d *Database) SelectCoursesByRooms(ctx context.Context, term int, rooms []int) ([]*models.Course, error) {
+	if len(rooms) == 0 {
+		return nil, nil
+	}
+
+	rows, err := d.db.QueryContext(ctx, "SELECT "+courseColumns+" FROM courses WHERE term=? AND room IN "+inPlaceholders(len(rooms)), append([]interface{}{term}, intArgs(rooms)...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var courses []*models.Course
+	for rows.Next() {
+		course := &models.Course{}
+		if err := rows.Scan(&course.Id, &course.Title, &course.Term, &course.School, &course.Instructor, &course.Subject, &course.CatalogNum, &course.Section, &course.Room, &course.MeetingDays, &course.StartTime, &course.EndTime, &course.StartDate, &course.EndDate, &course.Seats, &course.Overview, &course.Topic, &course.Attributes, &course.Requirements, &course.Component, &course.ClassNum, &course.CourseId); err != nil {
+			return nil, err
+		}
+		courses = append(courses, course)
+	}
+
+	return courses, nil
+}
+
+// SelectCoursesByBuildings returns a term's courses in each of buildings,
+// ordered by id.
+func (d *Database) SelectCoursesByBuildings(ctx context.Context, term int, buildings []int) (map[int][]*models.Course, error) {
+	if len(buildings) == 0 {
+		return nil, nil
+	}
+
+	rows, err := d.db.QueryContext(ctx, "SELECT rooms.building_id, "+courseColumns+" FROM courses JOIN rooms ON rooms.id=courses.room WHERE term=? AND rooms.building_id IN "+inPlaceholders(len(buildings))+" ORDER BY courses.id", append([]interface{}{term}, intArgs(buildings)...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	courses := make(map[int][]*models.Course)
+	for rows.Next() {
+		var building int
+		course := &models.Course{}
+		if err := rows.Scan(&building, &course.Id, &course.Title, &course.Term, &course.School, &course.Instructor, &course.Subject, &course.CatalogNum, &course.Section, &course.Room, &course.MeetingDays, &course.StartTime, &course.EndTime, &course.StartDate, &course.EndDate, &course.Seats, &course.Overview, &course.Topic, &course.Attributes, &course.Requirements, &course.Component, &course.ClassNum, &course.CourseId); err != nil {
+			return nil, err
+		}
+		courses[building] = append(courses[building], course)
+	}
+
+	return courses, nil
+}
@@ -0,0 +1,209 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/andrewmthomas87/northwestern/apierror"
+	"github.com/andrewmthomas87/northwestern/models"
+)
+
+type correctableTable struct {
+	name    string
+	columns map[string]string
+}
+
+// correctableTables maps each correction entity to its table and the GraphQL
+// field names that may be corrected to their columns.
+var correctableTables = map[models.CorrectionEntity]*correctableTable{
+	models.CorrectionEntityCourse: {
+		name: "courses",
+		columns: map[string]string{
+			"title":        "title",
+			"room":         "room",
+			"meetingDays":  "meeting_days",
+			"startTime":    "start_time",
+			"endTime":      "end_time",
+			"startDate":    "start_date",
+			"endDate":      "end_date",
+			"seats":        "seats",
+			"overview":     "overview",
+			"topic":        "topic",
+			"requirements": "requirements",
+		},
+	},
+	models.CorrectionEntityBuilding: {
+		name: "buildings",
+		columns: map[string]string{
+			"name": "name",
+			"lat":  "lat",
+			"lon":  "lon",
+		},
+	},
+	models.CorrectionEntityRoom: {
+		name: "rooms",
+		columns: map[string]string{
+			"name":       "name",
+			"buildingId": "building_id",
+		},
+	},
+}
+
+func dateValue(s string) (interface{}, error) {
+	return models.ParseDate(s)
+}
+
+func timeValue(s string) (interface{}, error) {
+	return models.ParseTime(s)
+}
+
+// typedColumns parse corrections to DATE and TIME columns the same way the
+// scraper parses upstream values.
+var typedColumns = map[string]func(string) (interface{}, error){
+	"start_time": timeValue,
+	"end_time":   timeValue,
+	"start_date": dateValue,
+	"end_date":   dateValue,
+}
+
+func correctionValue(column string, value string) (interface{}, error) {
+	if parse, ok := typedColumns[column]; ok {
+		return parse(value)
+	}
+	return value, nil
+}
+
+func correctionColumn(entity models.CorrectionEntity, field string) (string, string, error) {
+	table, ok := correctableTables[entity]
+	if !ok {
+		return "", "", apierror.Errorf(apierror.InvalidArgument, "%s can't be corrected", entity)
+	}
+
+	column, ok := table.columns[field]
+	if !ok {
+		return "", "", apierror.Errorf(apierror.InvalidArgument, "%s.%s can't be corrected", entity, field)
+	}
+
+	return table.name, column, nil
+}
+
+// InsertDataCorrection stores a correction and applies it right away. The
+// scraper reapplies corrections after every run, since it overwrites rows.
+func (d *Database) InsertDataCorrection(ctx context.Context, entity models.CorrectionEntity, recordId int, field string, value string, createdBy string) (*models.DataCorrection, error) {
+	table, column, err := correctionColumn(entity, field)
+	if err != nil {
+		return nil, err
+	}
+	typedValue, err := correctionValue(column, value)
+	if err != nil {
+		return nil, apierror.New(apierror.InvalidArgument, err.Error())
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := tx.ExecContext(ctx, "INSERT INTO data_corrections (entity, record_id, field, value, created_by, created_at) VALUES (?, ?, ?, ?, ?, UTC_TIMESTAMP()) ON DUPLICATE KEY UPDATE id=LAST_INSERT_ID(id), value=VALUES(value), created_by=VALUES(created_by), created_at=VALUES(created_at)", entity, recordId, field, value, createdBy)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return nil, err
+		}
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET %s=? WHERE id=?", table, column), typedValue, recordId); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return nil, err
+		}
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	row := d.db.QueryRowContext(ctx, "SELECT id, entity, record_id, field, value, created_by, created_at FROM data_corrections WHERE id=?", id)
+
+	correction := &models.DataCorrection{}
+	if err := row.Scan(&correction.ID, &correction.Entity, &correction.RecordID, &correction.Field, &correction.Value, &correction.CreatedBy, &correction.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return correction, nil
+}
+
+// DeleteDataCorrection stops reapplying a correction. The corrected value stays
+// in place until the next scrape overwrites it.
+func (d *Database) DeleteDataCorrection(ctx context.Context, id int) (bool, error) {
+	result, err := d.db.ExecContext(ctx, "DELETE FROM data_corrections WHERE id=?", id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (d *Database) SelectAllDataCorrections(ctx context.Context) ([]*models.DataCorrection, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT id, entity, record_id, field, value, created_by, created_at FROM data_corrections ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var corrections []*models.DataCorrection
+	for rows.Next() {
+		correction := &models.DataCorrection{}
+		if err := rows.Scan(&correction.ID, &correction.Entity, &correction.RecordID, &correction.Field, &correction.Value, &correction.CreatedBy, &correction.CreatedAt); err != nil {
+			return nil, err
+		}
+		corrections = append(corrections, correction)
+	}
+
+	return corrections, nil
+}
+
+func (d *Database) ApplyDataCorrections(ctx context.Context) error {
+	corrections, err := d.SelectAllDataCorrections(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, correction := range corrections {
+		table, column, err := correctionColumn(correction.Entity, correction.Field)
+		if err != nil {
+			continue
+		}
+		value, err := correctionValue(column, correction.Value)
+		if err != nil {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET %s=? WHERE id=?", table, column), value, correction.RecordID); err != nil {
+			if err := tx.Rollback(); err != nil {
+				return err
+			}
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return nil
+}
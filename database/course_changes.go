@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"github.com/andrewmthomas87/northwestern/models"
+	"strings"
+)
+
+func (d *Database) InsertCourseChanges(ctx context.Context, changes []*models.CourseChange) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO course_changes (course, term, subject, kind, fields, previous_seats, seats, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, UTC_TIMESTAMP())")
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		_, err := stmt.Exec(change.Course, change.Term, change.Subject, change.Kind, strings.Join(change.Fields, ","), change.PreviousSeats, change.Seats)
+		if err != nil {
+			if err := tx.Rollback(); err != nil {
+				return err
+			}
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (d *Database) SelectLastCourseChangeID(ctx context.Context) (int, error) {
+	var id int
+	if err := d.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM course_changes").Scan(&id); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (d *Database) SelectCourseChangesAfter(ctx context.Context, id int, limit int) ([]*models.CourseChange, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT id, course, term, subject, kind, fields, previous_seats, seats, created_at FROM course_changes WHERE id>? ORDER BY id LIMIT ?", id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []*models.CourseChange
+	for rows.Next() {
+		change := &models.CourseChange{}
+		var fields string
+		if err := rows.Scan(&change.ID, &change.Course, &change.Term, &change.Subject, &change.Kind, &fields, &change.PreviousSeats, &change.Seats, &change.CreatedAt); err != nil {
+			return nil, err
+		}
+		if len(fields) > 0 {
+			change.Fields = strings.Split(fields, ",")
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// DeleteCourseChangesBefore drops changes older than days, which everything
+// reading them has long since seen.
+func (d *Database) DeleteCourseChangesBefore(ctx context.Context, days int) error {
+	_, err := d.db.ExecContext(ctx, "DELETE FROM course_changes WHERE created_at < UTC_TIMESTAMP() - INTERVAL ? DAY", days)
+	return err
+}
@@ -3,9 +3,12 @@ package database
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"github.com/andrewmthomas87/northwestern/models"
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
+	"net"
+	"strings"
 )
 
 type Database struct {
@@ -21,6 +24,34 @@ func NewDatabase(user, password, host string, port int, database string) (*Datab
 	return &Database{db: db}, nil
 }
 
+// unavailableErrors are the MySQL error numbers that mean the server is out of
+// capacity or gave up waiting, rather than that the query was wrong.
+var unavailableErrors = map[uint16]bool{
+	1040: true, // too many connections
+	1053: true, // server shutdown in progress
+	1203: true, // user has too many connections
+	1205: true, // lock wait timeout
+	1213: true, // deadlock
+	1226: true, // user resource limit reached
+	1927: true, // connection killed
+	3024: true, // max_execution_time exceeded
+}
+
+// Unavailable reports whether err means the database couldn't be reached or
+// couldn't take the query right now, as opposed to a query failing.
+func Unavailable(err error) bool {
+	if err == driver.ErrBadConn || err == mysql.ErrInvalidConn || err == context.DeadlineExceeded {
+		return true
+	}
+	switch e := err.(type) {
+	case *net.OpError:
+		return true
+	case *mysql.MySQLError:
+		return unavailableErrors[e.Number]
+	}
+	return false
+}
+
 func (d *Database) InsertTerms(ctx context.Context, terms []*models.Term) error {
 	tx, err := d.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -189,6 +220,17 @@ func (d *Database) SelectSubjectsByTerm(ctx context.Context, term int) ([]*model
 	return subjects, nil
 }
 
+func (d *Database) SelectSubject(ctx context.Context, symbol string) (*models.Subject, error) {
+	row := d.db.QueryRowContext(ctx, "SELECT symbol, name FROM subjects WHERE symbol=?", symbol)
+
+	subject := &models.Subject{}
+	if err := row.Scan(&subject.Symbol, &subject.Name); err != nil {
+		return nil, err
+	}
+
+	return subject, nil
+}
+
 func (d *Database) InsertSubjectAvailabilities(ctx context.Context, subjectAvailabilites []*models.SubjectAvailability) error {
 	tx, err := d.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -351,6 +393,36 @@ func (d *Database) SelectBuilding(ctx context.Context, id int) (*models.Building
 	return building, nil
 }
 
+func (d *Database) SelectBuildingsByIds(ctx context.Context, ids []int) ([]*models.Building, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	rows, err := d.db.QueryContext(ctx, "SELECT id, name, lat, lon FROM buildings WHERE id IN ("+strings.Join(placeholders, ", ")+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buildings []*models.Building
+	for rows.Next() {
+		building := &models.Building{}
+		if err := rows.Scan(&building.Id, &building.Name, &building.Lat, &building.Lon); err != nil {
+			return nil, err
+		}
+		buildings = append(buildings, building)
+	}
+
+	return buildings, nil
+}
+
 func (d *Database) InsertRooms(ctx context.Context, rooms []*models.Room) error {
 	tx, err := d.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -416,6 +488,36 @@ func (d *Database) SelectRoomsByBuilding(ctx context.Context, building int) ([]*
 	return rooms, nil
 }
 
+func (d *Database) SelectRoomsByIds(ctx context.Context, ids []int) ([]*models.Room, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	rows, err := d.db.QueryContext(ctx, "SELECT id, building_id, name FROM rooms WHERE id IN ("+strings.Join(placeholders, ", ")+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rooms []*models.Room
+	for rows.Next() {
+		room := &models.Room{}
+		if err := rows.Scan(&room.Id, &room.BuildingId, &room.Name); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, room)
+	}
+
+	return rooms, nil
+}
+
 func (d *Database) InsertCourses(ctx context.Context, courses []*models.Course) error {
 	tx, err := d.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -497,6 +599,293 @@ func (d *Database) InsertCourseComponents(ctx context.Context, courseComponents
 	return nil
 }
 
+const courseColumns = "courses.id, title, term, school, instructor, subject, catalog_num, section, room, meeting_days, start_time, end_time, start_date, end_date, seats, overview, topic, attributes, requirements, component, class_num, course_id"
+
+// courseFilter returns the FROM clause and conditions that select a term's
+// courses, optionally narrowed to a subject and an attribute.
+func courseFilter(term int, subject *string, attribute *string) (string, []string, []interface{}) {
+	from := " FROM courses"
+	var conditions []string
+	var args []interface{}
+	if attribute != nil {
+		from += " JOIN course_attributes ON course_attributes.course=courses.id"
+		conditions = append(conditions, "course_attributes.attribute=?")
+		args = append(args, *attribute)
+	}
+	conditions = append(conditions, "term=?")
+	args = append(args, term)
+	if subject != nil {
+		conditions = append(conditions, "subject=?")
+		args = append(args, *subject)
+	}
+
+	return from, conditions, args
+}
+
+func (d *Database) SelectCourses(ctx context.Context, term int, subject *string, attribute *string) ([]*models.Course, error) {
+	from, conditions, args := courseFilter(term, subject, attribute)
+	query := "SELECT " + courseColumns + from + " WHERE " + strings.Join(conditions, " AND ")
+
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var courses []*models.Course
+	for rows.Next() {
+		course := &models.Course{}
+		if err := rows.Scan(&course.Id, &course.Title, &course.Term, &course.School, &course.Instructor, &course.Subject, &course.CatalogNum, &course.Section, &course.Room, &course.MeetingDays, &course.StartTime, &course.EndTime, &course.StartDate, &course.EndDate, &course.Seats, &course.Overview, &course.Topic, &course.Attributes, &course.Requirements, &course.Component, &course.ClassNum, &course.CourseId); err != nil {
+			return nil, err
+		}
+		courses = append(courses, course)
+	}
+
+	return courses, nil
+}
+
+func (d *Database) SelectCoursesBySubjects(ctx context.Context, subjects []string) ([]*models.Course, error) {
+	placeholders := make([]string, len(subjects))
+	args := make([]interface{}, len(subjects))
+	for i, subject := range subjects {
+		placeholders[i] = "?"
+		args[i] = subject
+	}
+
+	rows, err := d.db.QueryContext(ctx, "SELECT id, title, term, school, instructor, subject, catalog_num, section, room, meeting_days, start_time, end_time, start_date, end_date, seats, overview, topic, attributes, requirements, component, class_num, course_id FROM courses WHERE subject IN ("+strings.Join(placeholders, ", ")+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var courses []*models.Course
+	for rows.Next() {
+		course := &models.Course{}
+		if err := rows.Scan(&course.Id, &course.Title, &course.Term, &course.School, &course.Instructor, &course.Subject, &course.CatalogNum, &course.Section, &course.Room, &course.MeetingDays, &course.StartTime, &course.EndTime, &course.StartDate, &course.EndDate, &course.Seats, &course.Overview, &course.Topic, &course.Attributes, &course.Requirements, &course.Component, &course.ClassNum, &course.CourseId); err != nil {
+			return nil, err
+		}
+		courses = append(courses, course)
+	}
+
+	return courses, nil
+}
+
+func (d *Database) SelectCoursesByIds(ctx context.Context, ids []int) ([]*models.Course, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	rows, err := d.db.QueryContext(ctx, "SELECT id, title, term, school, instructor, subject, catalog_num, section, room, meeting_days, start_time, end_time, start_date, end_date, seats, overview, topic, attributes, requirements, component, class_num, course_id FROM courses WHERE id IN ("+strings.Join(placeholders, ", ")+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var courses []*models.Course
+	for rows.Next() {
+		course := &models.Course{}
+		if err := rows.Scan(&course.Id, &course.Title, &course.Term, &course.School, &course.Instructor, &course.Subject, &course.CatalogNum, &course.Section, &course.Room, &course.MeetingDays, &course.StartTime, &course.EndTime, &course.StartDate, &course.EndDate, &course.Seats, &course.Overview, &course.Topic, &course.Attributes, &course.Requirements, &course.Component, &course.ClassNum, &course.CourseId); err != nil {
+			return nil, err
+		}
+		courses = append(courses, course)
+	}
+
+	return courses, nil
+}
+
+func (d *Database) InsertAttributes(ctx context.Context, attributes []*models.Attribute) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare("REPLACE INTO attributes (symbol, name, category) VALUES (?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	for _, attribute := range attributes {
+		_, err := stmt.Exec(attribute.Symbol, attribute.Name, attribute.Category)
+		if err != nil {
+			if err := tx.Rollback(); err != nil {
+				return err
+			}
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (d *Database) SelectAllAttributes(ctx context.Context) ([]*models.Attribute, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT symbol, name, category FROM attributes")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attributes []*models.Attribute
+	for rows.Next() {
+		attribute := &models.Attribute{}
+		if err := rows.Scan(&attribute.Symbol, &attribute.Name, &attribute.Category); err != nil {
+			return nil, err
+		}
+		attributes = append(attributes, attribute)
+	}
+
+	return attributes, nil
+}
+
+func (d *Database) SelectAttributesByCourse(ctx context.Context, course int) ([]*models.Attribute, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT symbol, name, category FROM attributes, course_attributes WHERE symbol=attribute AND course=?", course)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attributes []*models.Attribute
+	for rows.Next() {
+		attribute := &models.Attribute{}
+		if err := rows.Scan(&attribute.Symbol, &attribute.Name, &attribute.Category); err != nil {
+			return nil, err
+		}
+		attributes = append(attributes, attribute)
+	}
+
+	return attributes, nil
+}
+
+func (d *Database) InsertCourseAttributes(ctx context.Context, courseAttributes []*models.CourseAttribute) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare("INSERT IGNORE INTO course_attributes (course, attribute) VALUES (?, ?)")
+	if err != nil {
+		return err
+	}
+	for _, courseAttribute := range courseAttributes {
+		_, err := stmt.Exec(courseAttribute.Course, courseAttribute.Attribute)
+		if err != nil {
+			if err := tx.Rollback(); err != nil {
+				return err
+			}
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (d *Database) DeleteCourseAttributesByTerm(ctx context.Context, termId int) error {
+	_, err := d.db.ExecContext(ctx, "DELETE course_attributes FROM course_attributes JOIN courses ON courses.id=course_attributes.course WHERE courses.term=?", termId)
+	return err
+}
+
+func (d *Database) InsertCourseRequirements(ctx context.Context, courseRequirements []*models.CourseRequirement) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare("INSERT IGNORE INTO course_requirements (course, subject, catalog_num) VALUES (?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	for _, courseRequirement := range courseRequirements {
+		_, err := stmt.Exec(courseRequirement.Course, courseRequirement.Subject, courseRequirement.CatalogNum)
+		if err != nil {
+			if err := tx.Rollback(); err != nil {
+				return err
+			}
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (d *Database) SelectCourseRequirementsBySubject(ctx context.Context, subject string) ([]*models.CourseRequirement, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT course, course_requirements.subject, course_requirements.catalog_num FROM course_requirements JOIN courses ON courses.id=course_requirements.course WHERE courses.subject=?", subject)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var courseRequirements []*models.CourseRequirement
+	for rows.Next() {
+		courseRequirement := &models.CourseRequirement{}
+		if err := rows.Scan(&courseRequirement.Course, &courseRequirement.Subject, &courseRequirement.CatalogNum); err != nil {
+			return nil, err
+		}
+		courseRequirements = append(courseRequirements, courseRequirement)
+	}
+
+	return courseRequirements, nil
+}
+
+func (d *Database) DeleteCourseRequirementsByTerm(ctx context.Context, termId int) error {
+	_, err := d.db.ExecContext(ctx, "DELETE course_requirements FROM course_requirements JOIN courses ON courses.id=course_requirements.course WHERE courses.term=?", termId)
+	return err
+}
+
 func (d *Database) Close() error {
 	return d.db.Close()
 }
+
+// courseDeletes remove a course and every row that refers to it, except the
+// notifications telling its watchers it was removed.
+var courseDeletes = []string{
+	"DELETE FROM courses WHERE id IN ",
+	"DELETE FROM course_descriptions WHERE course IN ",
+	"DELETE FROM course_components WHERE course IN ",
+	"DELETE FROM course_attributes WHERE course IN ",
+	"DELETE FROM course_requirements WHERE course IN ",
+	"DELETE FROM watches WHERE course IN ",
+	"DELETE FROM notifications WHERE kind<>'" + models.CourseRemoved + "' AND course IN ",
+}
+
+func (d *Database) DeleteCourses(ctx context.Context, ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, query := range courseDeletes {
+		if _, err := tx.ExecContext(ctx, query+inPlaceholders(len(ids)), intArgs(ids)...); err != nil {
+			if err := tx.Rollback(); err != nil {
+				return err
+			}
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return nil
+}
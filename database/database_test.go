@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"github.com/go-sql-driver/mysql"
+	"net"
+	"testing"
+)
+
+func TestUnavailable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "bad connection", err: driver.ErrBadConn, want: true},
+		{name: "invalid connection", err: mysql.ErrInvalidConn, want: true},
+		{name: "deadline", err: context.DeadlineExceeded, want: true},
+		{name: "dial", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: true},
+		{name: "too many connections", err: &mysql.MySQLError{Number: 1040, Message: "Too many connections"}, want: true},
+		{name: "lock wait timeout", err: &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}, want: true},
+		{name: "syntax error", err: &mysql.MySQLError{Number: 1064, Message: "You have an error in your SQL syntax"}, want: false},
+		{name: "duplicate entry", err: &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}, want: false},
+		{name: "other", err: errors.New("something else"), want: false},
+	}
+	for _, test := range tests {
+		if got := Unavailable(test.err); got != test.want {
+			t.Errorf("%s: Unavailable(%v) = %v, want %v", test.name, test.err, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+type dateColumn struct {
+	table   string
+	column  string
+	key     []string
+	sqlType string
+	parse   func(string) (interface{}, error)
+	// required columns are NOT NULL: the migration stops rather than leave one
+	// empty, and missing values are flagged too.
+	required bool
+}
+
+// dateColumns are the columns that used to hold upstream's date and time
+// strings as they were sent.
+var dateColumns = []*dateColumn{
+	{table: "terms", column: "start_date", key: []string{"id"}, sqlType: "DATE", parse: dateValue, required: true},
+	{table: "terms", column: "end_date", key: []string{"id"}, sqlType: "DATE", parse: dateValue, required: true},
+	{table: "courses", column: "start_time", key: []string{"id"}, sqlType: "TIME", parse: timeValue},
+	{table: "courses", column: "end_time", key: []string{"id"}, sqlType: "TIME", parse: timeValue},
+	{table: "courses", column: "start_date", key: []string{"id"}, sqlType: "DATE", parse: dateValue},
+	{table: "courses", column: "end_date", key: []string{"id"}, sqlType: "DATE", parse: dateValue},
+	{table: "course_components", column: "start_time", key: []string{"course", "component", "section"}, sqlType: "TIME", parse: timeValue},
+	{table: "course_components", column: "end_time", key: []string{"course", "component", "section"}, sqlType: "TIME", parse: timeValue},
+}
+
+// UnparsedValue is a stored value the date migration couldn't read. It's
+// left NULL, unless the column is required, in which case nothing is converted.
+type UnparsedValue struct {
+	Table  string
+	Key    string
+	Column string
+	Value  string
+}
+
+func (d *Database) columnType(ctx context.Context, table string, column string) (string, error) {
+	var dataType string
+	row := d.db.QueryRowContext(ctx, "SELECT DATA_TYPE FROM information_schema.COLUMNS WHERE TABLE_SCHEMA=DATABASE() AND TABLE_NAME=? AND COLUMN_NAME=?", table, column)
+	if err := row.Scan(&dataType); err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return strings.ToUpper(dataType), nil
+}
+
+// MigrateDateColumns converts the date and time columns from strings to DATE
+// and TIME, parsing every stored value into a new column before swapping it in.
+// Columns that are already converted are skipped, so it can be run again after
+// a failure. A required column with values that don't parse is an error, and
+// the values are returned with it so they can be fixed first.
+func (d *Database) MigrateDateColumns(ctx context.Context) ([]*UnparsedValue, error) {
+	var unparsed []*UnparsedValue
+	for _, c := range dateColumns {
+		columnUnparsed, err := d.migrateDateColumn(ctx, c)
+		unparsed = append(unparsed, columnUnparsed...)
+		if err != nil {
+			return unparsed, fmt.Errorf("%s.%s: %s", c.table, c.column, err)
+		}
+	}
+
+	return unparsed, nil
+}
+
+func (d *Database) migrateDateColumn(ctx context.Context, c *dateColumn) ([]*UnparsedValue, error) {
+	typed := c.column + "_typed"
+
+	currentType, err := d.columnType(ctx, c.table, c.column)
+	if err != nil {
+		return nil, err
+	}
+	typedType, err := d.columnType(ctx, c.table, typed)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case currentType == c.sqlType:
+		return nil, nil
+	case currentType == "" && typedType == c.sqlType:
+		// A previous run dropped the old column but didn't get to the rename.
+		return nil, d.renameTypedColumn(ctx, c, typed)
+	case currentType == "":
+		return nil, fmt.Errorf("column is missing")
+	}
+
+	if typedType == "" {
+		if _, err := d.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s NULL", c.table, typed, c.sqlType)); err != nil {
+			return nil, err
+		}
+	}
+
+	unparsed, err := d.backfillDateColumn(ctx, c, typed)
+	if err != nil {
+		return nil, err
+	}
+	if c.required && len(unparsed) > 0 {
+		return unparsed, fmt.Errorf("%d values couldn't be parsed and the column can't be empty", len(unparsed))
+	}
+
+	if _, err := d.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", c.table, c.column)); err != nil {
+		return nil, err
+	}
+
+	return unparsed, d.renameTypedColumn(ctx, c, typed)
+}
+
+func (d *Database) renameTypedColumn(ctx context.Context, c *dateColumn, typed string) error {
+	null := "NULL"
+	if c.required {
+		null = "NOT NULL"
+	}
+	_, err := d.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s CHANGE COLUMN %s %s %s %s", c.table, typed, c.column, c.sqlType, null))
+	return err
+}
+
+func (d *Database) backfillDateColumn(ctx context.Context, c *dateColumn, typed string) ([]*UnparsedValue, error) {
+	rows, err := d.db.QueryContext(ctx, fmt.Sprintf("SELECT %s, %s FROM %s", strings.Join(c.key, ", "), c.column, c.table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type update struct {
+		key   []interface{}
+		value interface{}
+	}
+
+	var updates []*update
+	var unparsed []*UnparsedValue
+	for rows.Next() {
+		key := make([]sql.NullString, len(c.key))
+		dest := make([]interface{}, len(c.key)+1)
+		for i := range key {
+			dest[i] = &key[i]
+		}
+		var raw sql.NullString
+		dest[len(c.key)] = &raw
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		u := &update{key: make([]interface{}, len(key))}
+		keyParts := make([]string, len(key))
+		for i := range key {
+			u.key[i] = key[i]
+			keyParts[i] = fmt.Sprintf("%s=%s", c.key[i], key[i].String)
+		}
+
+		value, err := c.parse(raw.String)
+		if err == nil && c.required {
+			if v, _ := value.(driver.Valuer).Value(); v == nil {
+				err = fmt.Errorf("missing value")
+			}
+		}
+		if err != nil {
+			unparsed = append(unparsed, &UnparsedValue{Table: c.table, Key: strings.Join(keyParts, " "), Column: c.column, Value: raw.String})
+			continue
+		}
+		u.value = value
+		updates = append(updates, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	where := make([]string, len(c.key))
+	for i, column := range c.key {
+		where[i] = column + "<=>?"
+	}
+	stmt, err := tx.Prepare(fmt.Sprintf("UPDATE %s SET %s=? WHERE %s", c.table, typed, strings.Join(where, " AND ")))
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return nil, err
+		}
+		return nil, err
+	}
+	defer stmt.Close()
+
+	for _, u := range updates {
+		if _, err := stmt.ExecContext(ctx, append([]interface{}{u.value}, u.key...)...); err != nil {
+			if err := tx.Rollback(); err != nil {
+				return nil, err
+			}
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return unparsed, nil
+}
@@ -0,0 +1,153 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"github.com/andrewmthomas87/northwestern/models"
+)
+
+func (d *Database) InsertInviteCode(ctx context.Context, code string, maxUses int, expiresAt *models.DateTime) error {
+	_, err := d.db.ExecContext(ctx, "INSERT INTO invite_codes (code, max_uses, expires_at) VALUES (?, ?, ?)", code, maxUses, expiresAt)
+	return err
+}
+
+func (d *Database) IsInvited(ctx context.Context, email string) (bool, error) {
+	row := d.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM invited_users WHERE email=?", email)
+
+	var count int
+	if err := row.Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (d *Database) RedeemInvite(ctx context.Context, code string, email string) (bool, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	var uses, maxUses int
+	row := tx.QueryRowContext(ctx, "SELECT uses, max_uses FROM invite_codes WHERE code=? AND (expires_at IS NULL OR expires_at > NOW()) FOR UPDATE", code)
+	if err := row.Scan(&uses, &maxUses); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return false, err
+		}
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if uses >= maxUses {
+		if err := tx.Rollback(); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE invite_codes SET uses=uses+1 WHERE code=?", code); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return false, err
+		}
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT IGNORE INTO invited_users (email, code) VALUES (?, ?)", email, code); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return false, err
+		}
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (d *Database) IsDenied(ctx context.Context, email string) (bool, error) {
+	row := d.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM denied_users WHERE email=?", email)
+
+	var count int
+	if err := row.Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// AllowUser adds email to the invited users without an invite code, taking it
+// off the denied users.
+func (d *Database) AllowUser(ctx context.Context, email string) error {
+	return d.moveUser(ctx, "REPLACE INTO invited_users (email, code) VALUES (?, NULL)", "DELETE FROM denied_users WHERE email=?", email)
+}
+
+// DisallowUser adds email to the denied users, which are refused even if they
+// match an allowed domain, and takes it off the invited users.
+func (d *Database) DisallowUser(ctx context.Context, email string) error {
+	return d.moveUser(ctx, "INSERT IGNORE INTO denied_users (email) VALUES (?)", "DELETE FROM invited_users WHERE email=?", email)
+}
+
+func (d *Database) moveUser(ctx context.Context, insert string, delete string, email string) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, insert, email); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return err
+		}
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, delete, email); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return err
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (d *Database) SelectInvitedUsers(ctx context.Context) ([]string, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT email FROM invited_users ORDER BY email")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var emails []string
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, err
+		}
+		emails = append(emails, email)
+	}
+
+	return emails, nil
+}
+
+func (d *Database) SelectDeniedUsers(ctx context.Context) ([]string, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT email FROM denied_users ORDER BY email")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var emails []string
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, err
+		}
+		emails = append(emails, email)
+	}
+
+	return emails, nil
+}
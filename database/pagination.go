@@ -0,0 +1,207 @@
+package database
+
+import (
+	"context"
+	"github.com/andrewmthomas87/northwestern/models"
+	"reflect"
+	"strings"
+)
+
+// Page is a keyset pagination window over rows ordered by a unique column.
+// Only rows after After and before Before are considered, and Limit of them
+// are taken from the start, or from the end when Backward is set.
+type Page struct {
+	After    interface{}
+	Before   interface{}
+	Limit    int
+	Backward bool
+}
+
+// apply adds the page's bounds, order and limit to query. It fetches one row
+// past the limit so trim can tell whether there are more.
+func (p *Page) apply(query string, conditions []string, args []interface{}, column string) (string, []interface{}) {
+	if p.After != nil {
+		conditions = append(conditions, column+" > ?")
+		args = append(args, p.After)
+	}
+	if p.Before != nil {
+		conditions = append(conditions, column+" < ?")
+		args = append(args, p.Before)
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	direction := "ASC"
+	if p.Backward {
+		direction = "DESC"
+	}
+	query += " ORDER BY " + column + " " + direction + " LIMIT ?"
+	args = append(args, p.Limit+1)
+
+	return query, args
+}
+
+// trim drops the extra row fetched by apply and puts backward pages back in
+// ascending order. rows must point to a slice. It reports whether more rows
+// lie beyond the page.
+func (p *Page) trim(rows interface{}) bool {
+	v := reflect.ValueOf(rows).Elem()
+
+	more := v.Len() > p.Limit
+	if more {
+		v.Set(v.Slice(0, p.Limit))
+	}
+
+	if p.Backward {
+		swap := reflect.Swapper(v.Interface())
+		for i, j := 0, v.Len()-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+
+	return more
+}
+
+func (d *Database) count(ctx context.Context, query string, args ...interface{}) (int, error) {
+	row := d.db.QueryRowContext(ctx, query, args...)
+
+	var count int
+	if err := row.Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (d *Database) SelectTermsPage(ctx context.Context, page *Page) ([]*models.Term, bool, error) {
+	query, args := page.apply("SELECT id, name, start_date, end_date FROM terms", nil, nil, "id")
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var terms []*models.Term
+	for rows.Next() {
+		term := &models.Term{}
+		if err := rows.Scan(&term.Id, &term.Name, &term.StartDate, &term.EndDate); err != nil {
+			return nil, false, err
+		}
+		terms = append(terms, term)
+	}
+
+	more := page.trim(&terms)
+
+	return terms, more, nil
+}
+
+func (d *Database) CountTerms(ctx context.Context) (int, error) {
+	return d.count(ctx, "SELECT COUNT(*) FROM terms")
+}
+
+func (d *Database) SelectSubjectsPage(ctx context.Context, page *Page) ([]*models.Subject, bool, error) {
+	query, args := page.apply("SELECT symbol, name FROM subjects", nil, nil, "symbol")
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var subjects []*models.Subject
+	for rows.Next() {
+		subject := &models.Subject{}
+		if err := rows.Scan(&subject.Symbol, &subject.Name); err != nil {
+			return nil, false, err
+		}
+		subjects = append(subjects, subject)
+	}
+
+	more := page.trim(&subjects)
+
+	return subjects, more, nil
+}
+
+func (d *Database) CountSubjects(ctx context.Context) (int, error) {
+	return d.count(ctx, "SELECT COUNT(*) FROM subjects")
+}
+
+func (d *Database) SelectBuildingsPage(ctx context.Context, page *Page) ([]*models.Building, bool, error) {
+	query, args := page.apply("SELECT id, name, lat, lon FROM buildings", nil, nil, "id")
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var buildings []*models.Building
+	for rows.Next() {
+		building := &models.Building{}
+		if err := rows.Scan(&building.Id, &building.Name, &building.Lat, &building.Lon); err != nil {
+			return nil, false, err
+		}
+		buildings = append(buildings, building)
+	}
+
+	more := page.trim(&buildings)
+
+	return buildings, more, nil
+}
+
+func (d *Database) CountBuildings(ctx context.Context) (int, error) {
+	return d.count(ctx, "SELECT COUNT(*) FROM buildings")
+}
+
+func (d *Database) SelectRoomsPage(ctx context.Context, page *Page) ([]*models.Room, bool, error) {
+	query, args := page.apply("SELECT id, building_id, name FROM rooms", nil, nil, "id")
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var rooms []*models.Room
+	for rows.Next() {
+		room := &models.Room{}
+		if err := rows.Scan(&room.Id, &room.BuildingId, &room.Name); err != nil {
+			return nil, false, err
+		}
+		rooms = append(rooms, room)
+	}
+
+	more := page.trim(&rooms)
+
+	return rooms, more, nil
+}
+
+func (d *Database) CountRooms(ctx context.Context) (int, error) {
+	return d.count(ctx, "SELECT COUNT(*) FROM rooms")
+}
+
+func (d *Database) SelectCoursesPage(ctx context.Context, term int, subject *string, attribute *string, page *Page) ([]*models.Course, bool, error) {
+	from, conditions, args := courseFilter(term, subject, attribute)
+	query, args := page.apply("SELECT "+courseColumns+from, conditions, args, "courses.id")
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var courses []*models.Course
+	for rows.Next() {
+		course := &models.Course{}
+		if err := rows.Scan(&course.Id, &course.Title, &course.Term, &course.School, &course.Instructor, &course.Subject, &course.CatalogNum, &course.Section, &course.Room, &course.MeetingDays, &course.StartTime, &course.EndTime, &course.StartDate, &course.EndDate, &course.Seats, &course.Overview, &course.Topic, &course.Attributes, &course.Requirements, &course.Component, &course.ClassNum, &course.CourseId); err != nil {
+			return nil, false, err
+		}
+		courses = append(courses, course)
+	}
+
+	more := page.trim(&courses)
+
+	return courses, more, nil
+}
+
+func (d *Database) CountCourses(ctx context.Context, term int, subject *string, attribute *string) (int, error) {
+	from, conditions, args := courseFilter(term, subject, attribute)
+	return d.count(ctx, "SELECT COUNT(*)"+from+" WHERE "+strings.Join(conditions, " AND "), args...)
+}
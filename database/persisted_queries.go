@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// InsertPersistedQuery stores a query under its hash. Queries registered ahead
+// of time stay registered if a client later sends the same query.
+func (d *Database) InsertPersistedQuery(ctx context.Context, hash string, query string, registered bool) error {
+	_, err := d.db.ExecContext(ctx, "INSERT INTO persisted_queries (hash, query, registered, created_at, last_used_at) VALUES (?, ?, ?, UTC_TIMESTAMP(), UTC_TIMESTAMP()) ON DUPLICATE KEY UPDATE registered=registered OR VALUES(registered), last_used_at=UTC_TIMESTAMP()", hash, query, registered)
+	return err
+}
+
+// SelectPersistedQuery finds a query by its hash. Use is recorded at most once
+// an hour per query to keep lookups from writing on every request.
+func (d *Database) SelectPersistedQuery(ctx context.Context, hash string) (string, bool, error) {
+	var query string
+	var registered bool
+	if err := d.db.QueryRowContext(ctx, "SELECT query, registered FROM persisted_queries WHERE hash=?", hash).Scan(&query, &registered); err != nil {
+		return "", false, err
+	}
+
+	if _, err := d.db.ExecContext(ctx, "UPDATE persisted_queries SET last_used_at=UTC_TIMESTAMP() WHERE hash=? AND last_used_at < UTC_TIMESTAMP() - INTERVAL 1 HOUR", hash); err != nil {
+		return "", false, err
+	}
+
+	return query, registered, nil
+}
+
+// DeleteUnusedPersistedQueries deletes queries clients added that haven't been
+// looked up for retention. Registered queries are kept.
+func (d *Database) DeleteUnusedPersistedQueries(ctx context.Context, retention time.Duration) error {
+	_, err := d.db.ExecContext(ctx, "DELETE FROM persisted_queries WHERE NOT registered AND last_used_at < UTC_TIMESTAMP() - INTERVAL ? SECOND", int(retention/time.Second))
+	return err
+}
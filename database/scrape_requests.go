@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"github.com/andrewmthomas87/northwestern/models"
+)
+
+const scrapeRequestColumns = "id, term, requested_by, status, error, created_at, started_at, finished_at"
+
+func scanScrapeRequest(row interface{ Scan(...interface{}) error }) (*models.ScrapeRequest, error) {
+	request := &models.ScrapeRequest{}
+	if err := row.Scan(&request.ID, &request.Term, &request.RequestedBy, &request.Status, &request.Error, &request.CreatedAt, &request.StartedAt, &request.FinishedAt); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+func (d *Database) InsertScrapeRequest(ctx context.Context, term *string, requestedBy string) (*models.ScrapeRequest, error) {
+	result, err := d.db.ExecContext(ctx, "INSERT INTO scrape_requests (term, requested_by, status, created_at) VALUES (?, ?, ?, UTC_TIMESTAMP())", term, requestedBy, models.ScrapeStatusPending)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return d.SelectScrapeRequest(ctx, int(id))
+}
+
+func (d *Database) SelectScrapeRequest(ctx context.Context, id int) (*models.ScrapeRequest, error) {
+	return scanScrapeRequest(d.db.QueryRowContext(ctx, "SELECT "+scrapeRequestColumns+" FROM scrape_requests WHERE id=?", id))
+}
+
+func (d *Database) SelectScrapeRequests(ctx context.Context, limit int) ([]*models.ScrapeRequest, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT "+scrapeRequestColumns+" FROM scrape_requests ORDER BY id DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []*models.ScrapeRequest
+	for rows.Next() {
+		request, err := scanScrapeRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+
+	return requests, nil
+}
+
+// ClaimScrapeRequest marks the oldest pending scrape request as running and
+// returns it, or nil if there is nothing to do.
+func (d *Database) ClaimScrapeRequest(ctx context.Context) (*models.ScrapeRequest, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := scanScrapeRequest(tx.QueryRowContext(ctx, "SELECT "+scrapeRequestColumns+" FROM scrape_requests WHERE status=? ORDER BY id LIMIT 1 FOR UPDATE", models.ScrapeStatusPending))
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return nil, err
+		}
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE scrape_requests SET status=?, started_at=UTC_TIMESTAMP() WHERE id=?", models.ScrapeStatusRunning, request.ID); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return nil, err
+		}
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	request.Status = models.ScrapeStatusRunning
+	return request, nil
+}
+
+// FinishScrapeRequest records the outcome of a claimed scrape request. A nil
+// scrapeErr means it succeeded.
+func (d *Database) FinishScrapeRequest(ctx context.Context, id int, scrapeErr error) error {
+	status := models.ScrapeStatusSucceeded
+	var message *string
+	if scrapeErr != nil {
+		status = models.ScrapeStatusFailed
+		errorMessage := scrapeErr.Error()
+		message = &errorMessage
+	}
+
+	_, err := d.db.ExecContext(ctx, "UPDATE scrape_requests SET status=?, error=?, finished_at=UTC_TIMESTAMP() WHERE id=?", status, message, id)
+	return err
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+func (d *Database) CreateSession(ctx context.Context, jti string, email string, refreshHash string, expiresAt time.Time) error {
+	if _, err := d.db.ExecContext(ctx, "INSERT INTO sessions (jti, email, refresh_hash, created_at, expires_at) VALUES (?, ?, ?, UTC_TIMESTAMP(), ?)", jti, email, refreshHash, expiresAt.UTC()); err != nil {
+		return err
+	}
+
+	return d.TouchUser(ctx, email)
+}
+
+func (d *Database) RotateSession(ctx context.Context, refreshHash string, jti string, newRefreshHash string, expiresAt time.Time) (string, bool, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", false, err
+	}
+
+	var currentJti, email, currentRefreshHash string
+	var active bool
+	row := tx.QueryRowContext(ctx, "SELECT jti, email, refresh_hash, NOT revoked AND expires_at > UTC_TIMESTAMP() FROM sessions WHERE refresh_hash=? OR previous_refresh_hash=? FOR UPDATE", refreshHash, refreshHash)
+	if err := row.Scan(&currentJti, &email, &currentRefreshHash, &active); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return "", false, err
+		}
+		if err == sql.ErrNoRows {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+
+	if currentRefreshHash != refreshHash {
+		if _, err := tx.ExecContext(ctx, "UPDATE sessions SET revoked=TRUE WHERE jti=?", currentJti); err != nil {
+			if err := tx.Rollback(); err != nil {
+				return "", false, err
+			}
+			return "", false, err
+		}
+
+		return "", false, tx.Commit()
+	}
+
+	if !active {
+		return "", false, tx.Rollback()
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE sessions SET jti=?, refresh_hash=?, previous_refresh_hash=?, expires_at=? WHERE jti=?", jti, newRefreshHash, refreshHash, expiresAt.UTC(), currentJti); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return "", false, err
+		}
+		return "", false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", false, err
+	}
+
+	return email, true, nil
+}
+
+func (d *Database) IsSessionActive(ctx context.Context, jti string) (bool, error) {
+	row := d.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sessions WHERE jti=? AND NOT revoked AND expires_at > UTC_TIMESTAMP()", jti)
+
+	var count int
+	if err := row.Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (d *Database) RevokeSession(ctx context.Context, jti string) error {
+	_, err := d.db.ExecContext(ctx, "UPDATE sessions SET revoked=TRUE WHERE jti=?", jti)
+	return err
+}
+
+func (d *Database) RevokeSessionByRefreshHash(ctx context.Context, refreshHash string) error {
+	_, err := d.db.ExecContext(ctx, "UPDATE sessions SET revoked=TRUE WHERE refresh_hash=?", refreshHash)
+	return err
+}
+
+func (d *Database) RevokeSessionsByEmail(ctx context.Context, email string) error {
+	_, err := d.db.ExecContext(ctx, "UPDATE sessions SET revoked=TRUE WHERE email=?", email)
+	return err
+}
+
+func (d *Database) DeleteExpiredSessions(ctx context.Context) error {
+	_, err := d.db.ExecContext(ctx, "DELETE FROM sessions WHERE expires_at < UTC_TIMESTAMP()")
+	return err
+}
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"context"
+	"github.com/andrewmthomas87/northwestern/models"
+)
+
+func (d *Database) TouchUser(ctx context.Context, email string) error {
+	_, err := d.db.ExecContext(ctx, "INSERT INTO users (email, created_at, last_sign_in_at) VALUES (?, UTC_TIMESTAMP(), UTC_TIMESTAMP()) ON DUPLICATE KEY UPDATE last_sign_in_at=UTC_TIMESTAMP()", email)
+	return err
+}
+
+func (d *Database) SelectUser(ctx context.Context, email string) (*models.User, error) {
+	row := d.db.QueryRowContext(ctx, "SELECT email, created_at, last_sign_in_at FROM users WHERE email=?", email)
+
+	user := &models.User{}
+	if err := row.Scan(&user.Email, &user.CreatedAt, &user.LastSignInAt); err != nil {
+		return nil, err
+	}
+
+	roles, err := d.SelectUserRoles(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	user.Roles = roles
+
+	return user, nil
+}
+
+func (d *Database) SelectAllUsers(ctx context.Context) ([]*models.User, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT email, created_at, last_sign_in_at FROM users ORDER BY email")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	usersMap := make(map[string]*models.User)
+	for rows.Next() {
+		user := &models.User{Roles: []models.Role{}}
+		if err := rows.Scan(&user.Email, &user.CreatedAt, &user.LastSignInAt); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+		usersMap[user.Email] = user
+	}
+
+	roleRows, err := d.db.QueryContext(ctx, "SELECT email, role FROM user_roles")
+	if err != nil {
+		return nil, err
+	}
+	defer roleRows.Close()
+
+	for roleRows.Next() {
+		var email string
+		var role models.Role
+		if err := roleRows.Scan(&email, &role); err != nil {
+			return nil, err
+		}
+		if user, ok := usersMap[email]; ok {
+			user.Roles = append(user.Roles, role)
+		}
+	}
+
+	return users, nil
+}
+
+func (d *Database) SelectUserRoles(ctx context.Context, email string) ([]models.Role, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT role FROM user_roles WHERE email=? ORDER BY role", email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []models.Role{}
+	for rows.Next() {
+		var role models.Role
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, nil
+}
+
+func (d *Database) HasRole(ctx context.Context, email string, role models.Role) (bool, error) {
+	row := d.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM user_roles WHERE email=? AND role=?", email, role)
+
+	var count int
+	if err := row.Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// GrantRole also creates the user, so roles can be handed out before someone
+// first signs in.
+func (d *Database) GrantRole(ctx context.Context, email string, role models.Role) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT IGNORE INTO users (email, created_at) VALUES (?, UTC_TIMESTAMP())", email); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return err
+		}
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT IGNORE INTO user_roles (email, role) VALUES (?, ?)", email, role); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return err
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (d *Database) RevokeRole(ctx context.Context, email string, role models.Role) error {
+	_, err := d.db.ExecContext(ctx, "DELETE FROM user_roles WHERE email=? AND role=?", email, role)
+	return err
+}
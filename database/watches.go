@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/andrewmthomas87/northwestern/models"
+	"strings"
+	"time"
+)
+
+func (d *Database) InsertWatch(ctx context.Context, email string, course int) error {
+	_, err := d.db.ExecContext(ctx, "INSERT IGNORE INTO watches (email, course, created_at) VALUES (?, ?, UTC_TIMESTAMP())", email, course)
+	return err
+}
+
+func (d *Database) DeleteWatch(ctx context.Context, email string, course int) (bool, error) {
+	result, err := d.db.ExecContext(ctx, "DELETE FROM watches WHERE email=? AND course=?", email, course)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+func (d *Database) SelectWatchedCourses(ctx context.Context, email string) ([]*models.Course, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT c.id, c.title, c.term, c.school, c.instructor, c.subject, c.catalog_num, c.section, c.room, c.meeting_days, c.start_time, c.end_time, c.start_date, c.end_date, c.seats, c.overview, c.topic, c.attributes, c.requirements, c.component, c.class_num, c.course_id FROM watches w JOIN courses c ON c.id=w.course WHERE w.email=? ORDER BY w.created_at", email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var courses []*models.Course
+	for rows.Next() {
+		course := &models.Course{}
+		if err := rows.Scan(&course.Id, &course.Title, &course.Term, &course.School, &course.Instructor, &course.Subject, &course.CatalogNum, &course.Section, &course.Room, &course.MeetingDays, &course.StartTime, &course.EndTime, &course.StartDate, &course.EndDate, &course.Seats, &course.Overview, &course.Topic, &course.Attributes, &course.Requirements, &course.Component, &course.ClassNum, &course.CourseId); err != nil {
+			return nil, err
+		}
+		courses = append(courses, course)
+	}
+
+	return courses, nil
+}
+
+// SelectNotificationPreferences returns the defaults for users who never set
+// any: immediate email.
+func (d *Database) SelectNotificationPreferences(ctx context.Context, email string) (*models.NotificationPreferences, error) {
+	preferences := &models.NotificationPreferences{Channel: models.NotificationChannelEmail, Frequency: models.NotificationFrequencyImmediate}
+	err := d.db.QueryRowContext(ctx, "SELECT channel, frequency, webhook_url FROM notification_preferences WHERE email=?", email).Scan(&preferences.Channel, &preferences.Frequency, &preferences.WebhookURL)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return preferences, nil
+}
+
+func (d *Database) UpdateNotificationPreferences(ctx context.Context, email string, preferences *models.NotificationPreferences) error {
+	_, err := d.db.ExecContext(ctx, "INSERT INTO notification_preferences (email, channel, frequency, webhook_url) VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE channel=VALUES(channel), frequency=VALUES(frequency), webhook_url=VALUES(webhook_url)", email, preferences.Channel, preferences.Frequency, preferences.WebhookURL)
+	return err
+}
+
+// InsertWatchNotifications queues a notification for everyone watching a
+// course that changed in the course changes after afterID, and for everyone
+// watching one of removed. Removed courses are about to be deleted, so their
+// notifications keep a copy of the course.
+func (d *Database) InsertWatchNotifications(ctx context.Context, afterID int, removed []*models.Course) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO notifications (email, course, kind, fields, previous_seats, seats, created_at) SELECT w.email, c.course, c.kind, c.fields, c.previous_seats, c.seats, UTC_TIMESTAMP() FROM course_changes c JOIN watches w ON w.course=c.course WHERE c.id>? AND c.kind=?", afterID, models.CourseUpdated); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return err
+		}
+		return err
+	}
+
+	for _, course := range removed {
+		snapshot, err := json.Marshal(course)
+		if err != nil {
+			if err := tx.Rollback(); err != nil {
+				return err
+			}
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, "INSERT INTO notifications (email, course, kind, snapshot, fields, previous_seats, seats, created_at) SELECT email, ?, ?, ?, '', ?, 0, UTC_TIMESTAMP() FROM watches WHERE course=?", course.Id, models.CourseRemoved, string(snapshot), course.Seats, course.Id); err != nil {
+			if err := tx.Rollback(); err != nil {
+				return err
+			}
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SelectPendingNotifications returns unsent notifications that are due, along
+// with the preferences of the users they're for. Digests are due once every
+// digestInterval; notifications that failed maxAttempts times are given up on.
+func (d *Database) SelectPendingNotifications(ctx context.Context, digestInterval time.Duration, maxAttempts int) ([]*models.Notification, map[string]*models.NotificationPreferences, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT n.id, n.email, n.course, n.kind, n.snapshot, n.fields, n.previous_seats, n.seats, n.created_at, COALESCE(p.channel, ?), COALESCE(p.frequency, ?), p.webhook_url FROM notifications n LEFT JOIN notification_preferences p ON p.email=n.email WHERE n.sent_at IS NULL AND n.attempts<? AND (p.frequency IS NULL OR p.frequency<>? OR p.last_digest_at IS NULL OR p.last_digest_at<=UTC_TIMESTAMP() - INTERVAL ? SECOND) ORDER BY n.email, n.id", models.NotificationChannelEmail, models.NotificationFrequencyImmediate, maxAttempts, models.NotificationFrequencyDigest, int(digestInterval/time.Second))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var notifications []*models.Notification
+	preferences := make(map[string]*models.NotificationPreferences)
+	for rows.Next() {
+		notification := &models.Notification{}
+		userPreferences := &models.NotificationPreferences{}
+		var snapshot sql.NullString
+		var fields string
+		if err := rows.Scan(&notification.ID, &notification.Email, &notification.Course, &notification.Kind, &snapshot, &fields, &notification.PreviousSeats, &notification.Seats, &notification.CreatedAt, &userPreferences.Channel, &userPreferences.Frequency, &userPreferences.WebhookURL); err != nil {
+			return nil, nil, err
+		}
+		if len(fields) > 0 {
+			notification.Fields = strings.Split(fields, ",")
+		}
+		if snapshot.Valid {
+			notification.Snapshot = &models.Course{}
+			if err := json.Unmarshal([]byte(snapshot.String), notification.Snapshot); err != nil {
+				return nil, nil, err
+			}
+		}
+		notifications = append(notifications, notification)
+		preferences[notification.Email] = userPreferences
+	}
+
+	return notifications, preferences, nil
+}
+
+func (d *Database) MarkNotificationsSent(ctx context.Context, ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := d.db.ExecContext(ctx, "UPDATE notifications SET sent_at=UTC_TIMESTAMP() WHERE id IN "+inPlaceholders(len(ids)), intArgs(ids)...)
+	return err
+}
+
+func (d *Database) MarkNotificationsFailed(ctx context.Context, ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := d.db.ExecContext(ctx, "UPDATE notifications SET attempts=attempts+1 WHERE id IN "+inPlaceholders(len(ids)), intArgs(ids)...)
+	return err
+}
+
+func (d *Database) UpdateNotificationDigestSent(ctx context.Context, email string) error {
+	_, err := d.db.ExecContext(ctx, "INSERT INTO notification_preferences (email, frequency, last_digest_at) VALUES (?, ?, UTC_TIMESTAMP()) ON DUPLICATE KEY UPDATE last_digest_at=UTC_TIMESTAMP()", email, models.NotificationFrequencyDigest)
+	return err
+}
@@ -0,0 +1,263 @@
+package database
+
+import (
+	"context"
+	"github.com/andrewmthomas87/northwestern/models"
+	"strings"
+	"time"
+)
+
+const (
+	webhookColumns         = "id, url, events, subjects, created_by, created_at"
+	webhookDeliveryColumns = "id, webhook, event, payload, status, attempts, last_status_code, last_error, created_at, next_attempt_at, delivered_at"
+)
+
+func scanWebhook(row interface{ Scan(...interface{}) error }) (*models.Webhook, error) {
+	webhook := &models.Webhook{}
+	var events string
+	var subjects *string
+	if err := row.Scan(&webhook.ID, &webhook.URL, &events, &subjects, &webhook.CreatedBy, &webhook.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	for _, event := range strings.Split(events, ",") {
+		webhook.Events = append(webhook.Events, models.WebhookEvent(event))
+	}
+	if subjects != nil {
+		webhook.Subjects = strings.Split(*subjects, ",")
+	}
+
+	return webhook, nil
+}
+
+func scanWebhookDelivery(row interface{ Scan(...interface{}) error }) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{}
+	if err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.Event, &delivery.Payload, &delivery.Status, &delivery.Attempts, &delivery.LastStatusCode, &delivery.LastError, &delivery.CreatedAt, &delivery.NextAttemptAt, &delivery.DeliveredAt); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// InsertWebhook stores a webhook. A nil subjects list matches every subject.
+func (d *Database) InsertWebhook(ctx context.Context, url string, secret string, events []models.WebhookEvent, subjects []string, createdBy string) (*models.Webhook, error) {
+	eventNames := make([]string, len(events))
+	for i, event := range events {
+		eventNames[i] = string(event)
+	}
+	var subjectList *string
+	if subjects != nil {
+		joined := strings.Join(subjects, ",")
+		subjectList = &joined
+	}
+
+	result, err := d.db.ExecContext(ctx, "INSERT INTO webhooks (url, secret, events, subjects, created_by, created_at) VALUES (?, ?, ?, ?, ?, UTC_TIMESTAMP())", url, secret, strings.Join(eventNames, ","), subjectList, createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return scanWebhook(d.db.QueryRowContext(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE id=?", id))
+}
+
+func (d *Database) SelectWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE NOT deleted ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []*models.Webhook
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, nil
+}
+
+func (d *Database) SelectWebhookEndpoint(ctx context.Context, id int) (*models.WebhookEndpoint, error) {
+	endpoint := &models.WebhookEndpoint{}
+	if err := d.db.QueryRowContext(ctx, "SELECT id, url, secret FROM webhooks WHERE id=? AND NOT deleted", id).Scan(&endpoint.ID, &endpoint.URL, &endpoint.Secret); err != nil {
+		return nil, err
+	}
+
+	return endpoint, nil
+}
+
+// DeleteWebhook keeps the webhook's row so its delivery log stays readable,
+// and gives up on anything still waiting to be delivered to it.
+func (d *Database) DeleteWebhook(ctx context.Context, id int) (bool, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := tx.ExecContext(ctx, "UPDATE webhooks SET deleted=TRUE WHERE id=? AND NOT deleted", id)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return false, err
+		}
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return false, err
+		}
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE webhook_deliveries SET status=?, last_error=?, next_attempt_at=NULL WHERE webhook=? AND status=?", models.WebhookDeliveryStatusDead, "webhook deleted", id, models.WebhookDeliveryStatusPending); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return false, err
+		}
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// InsertWebhookDeliveries queues deliveries to be sent as soon as possible.
+func (d *Database) InsertWebhookDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO webhook_deliveries (webhook, event, payload, status, created_at, next_attempt_at) VALUES (?, ?, ?, ?, UTC_TIMESTAMP(), UTC_TIMESTAMP())")
+	if err != nil {
+		return err
+	}
+	for _, delivery := range deliveries {
+		_, err := stmt.Exec(delivery.WebhookID, delivery.Event, delivery.Payload, models.WebhookDeliveryStatusPending)
+		if err != nil {
+			if err := tx.Rollback(); err != nil {
+				return err
+			}
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// InsertWebhookDelivery stores a delivery the caller attempts itself right
+// away. It has no next attempt time until that attempt fails, so the dispatcher
+// doesn't pick it up and send it a second time.
+func (d *Database) InsertWebhookDelivery(ctx context.Context, webhook int, event models.WebhookEvent, payload string) (*models.WebhookDelivery, error) {
+	result, err := d.db.ExecContext(ctx, "INSERT INTO webhook_deliveries (webhook, event, payload, status, created_at, next_attempt_at) VALUES (?, ?, ?, ?, UTC_TIMESTAMP(), NULL)", webhook, event, payload, models.WebhookDeliveryStatusPending)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return d.SelectWebhookDelivery(ctx, int(id))
+}
+
+func (d *Database) SelectWebhookDelivery(ctx context.Context, id int) (*models.WebhookDelivery, error) {
+	return scanWebhookDelivery(d.db.QueryRowContext(ctx, "SELECT "+webhookDeliveryColumns+" FROM webhook_deliveries WHERE id=?", id))
+}
+
+// SelectWebhookDeliveries returns the most recent deliveries first, optionally
+// only those for one webhook or in one status.
+func (d *Database) SelectWebhookDeliveries(ctx context.Context, webhook *int, status *models.WebhookDeliveryStatus, limit int) ([]*models.WebhookDelivery, error) {
+	conditions := []string{"TRUE"}
+	var args []interface{}
+	if webhook != nil {
+		conditions = append(conditions, "webhook=?")
+		args = append(args, *webhook)
+	}
+	if status != nil {
+		conditions = append(conditions, "status=?")
+		args = append(args, *status)
+	}
+	args = append(args, limit)
+
+	rows, err := d.db.QueryContext(ctx, "SELECT "+webhookDeliveryColumns+" FROM webhook_deliveries WHERE "+strings.Join(conditions, " AND ")+" ORDER BY id DESC LIMIT ?", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+func (d *Database) SelectDueWebhookDeliveries(ctx context.Context, limit int) ([]*models.WebhookDelivery, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT "+webhookDeliveryColumns+" FROM webhook_deliveries WHERE status=? AND next_attempt_at<=UTC_TIMESTAMP() ORDER BY next_attempt_at, id LIMIT ?", models.WebhookDeliveryStatusPending, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+func (d *Database) UpdateWebhookDeliverySucceeded(ctx context.Context, id int, statusCode int) error {
+	_, err := d.db.ExecContext(ctx, "UPDATE webhook_deliveries SET status=?, attempts=attempts+1, last_status_code=?, last_error=NULL, next_attempt_at=NULL, delivered_at=UTC_TIMESTAMP() WHERE id=?", models.WebhookDeliveryStatusSucceeded, statusCode, id)
+	return err
+}
+
+// UpdateWebhookDeliveryFailed records a failed attempt, scheduling the next
+// one after retryIn or, when dead, moving the delivery to the dead letter log.
+func (d *Database) UpdateWebhookDeliveryFailed(ctx context.Context, id int, statusCode *int, message string, retryIn time.Duration, dead bool) error {
+	status := models.WebhookDeliveryStatusPending
+	var retryAt *int
+	if dead {
+		status = models.WebhookDeliveryStatusDead
+	} else {
+		seconds := int(retryIn / time.Second)
+		retryAt = &seconds
+	}
+
+	_, err := d.db.ExecContext(ctx, "UPDATE webhook_deliveries SET status=?, attempts=attempts+1, last_status_code=?, last_error=?, next_attempt_at=IF(? IS NULL, NULL, UTC_TIMESTAMP() + INTERVAL ? SECOND) WHERE id=?", status, statusCode, message, retryAt, retryAt, id)
+	return err
+}
+
+// RetryWebhookDelivery puts a delivery back in the queue with a fresh set of
+// attempts. Deliveries to deleted webhooks are left where they are.
+func (d *Database) RetryWebhookDelivery(ctx context.Context, id int) (*models.WebhookDelivery, error) {
+	if _, err := d.db.ExecContext(ctx, "UPDATE webhook_deliveries d JOIN webhooks w ON w.id=d.webhook SET d.status=?, d.attempts=0, d.next_attempt_at=UTC_TIMESTAMP() WHERE d.id=? AND NOT w.deleted", models.WebhookDeliveryStatusPending, id); err != nil {
+		return nil, err
+	}
+
+	return d.SelectWebhookDelivery(ctx, id)
+}
@@ -0,0 +1,136 @@
+package dataloader
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BatchFunc fetches the values for keys in one go, returning them in the same
+// order as keys. It can return a single error for the whole batch or one per
+// key.
+type BatchFunc func(ctx context.Context, keys []interface{}) ([]interface{}, []error)
+
+type result struct {
+	value interface{}
+	err   error
+	done  chan struct{}
+}
+
+type batch struct {
+	keys    []interface{}
+	results []*result
+}
+
+// Loader collects the keys requested within wait of each other into one call
+// to its BatchFunc, and caches every result for its lifetime. Loaders are meant
+// to live for a single request, so the cache never goes stale.
+type Loader struct {
+	fetch    BatchFunc
+	wait     time.Duration
+	maxBatch int
+
+	mu    sync.Mutex
+	cache map[interface{}]*result
+	batch *batch
+}
+
+func NewLoader(fetch BatchFunc, wait time.Duration, maxBatch int) *Loader {
+	return &Loader{
+		fetch:    fetch,
+		wait:     wait,
+		maxBatch: maxBatch,
+		cache:    make(map[interface{}]*result),
+	}
+}
+
+func (l *Loader) Load(ctx context.Context, key interface{}) (interface{}, error) {
+	r := l.enqueue(ctx, key)
+	<-r.done
+
+	return r.value, r.err
+}
+
+// LoadAll loads several keys in the same batch.
+func (l *Loader) LoadAll(ctx context.Context, keys []interface{}) ([]interface{}, []error) {
+	results := make([]*result, len(keys))
+	for i, key := range keys {
+		results[i] = l.enqueue(ctx, key)
+	}
+
+	values := make([]interface{}, len(keys))
+	errs := make([]error, len(keys))
+	for i, r := range results {
+		<-r.done
+		values[i], errs[i] = r.value, r.err
+	}
+
+	return values, errs
+}
+
+func (l *Loader) enqueue(ctx context.Context, key interface{}) *result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if r, ok := l.cache[key]; ok {
+		return r
+	}
+
+	r := &result{done: make(chan struct{})}
+	l.cache[key] = r
+
+	if l.batch == nil {
+		l.batch = &batch{}
+		go l.dispatchAfterWait(ctx, l.batch)
+	}
+	b := l.batch
+	b.keys = append(b.keys, key)
+	b.results = append(b.results, r)
+
+	if l.maxBatch > 0 && len(b.keys) >= l.maxBatch {
+		l.batch = nil
+		go l.dispatch(ctx, b)
+	}
+
+	return r
+}
+
+func (l *Loader) dispatchAfterWait(ctx context.Context, b *batch) {
+	time.Sleep(l.wait)
+
+	l.mu.Lock()
+	if l.batch != b {
+		// Already dispatched for reaching maxBatch.
+		l.mu.Unlock()
+		return
+	}
+	l.batch = nil
+	l.mu.Unlock()
+
+	l.dispatch(ctx, b)
+}
+
+func (l *Loader) dispatch(ctx context.Context, b *batch) {
+	var values []interface{}
+	var errs []error
+	defer func() {
+		if p := recover(); p != nil {
+			values, errs = nil, []error{fmt.Errorf("dataloader: panic while loading: %v", p)}
+		}
+
+		for i, r := range b.results {
+			if i < len(values) {
+				r.value = values[i]
+			}
+			if len(errs) == 1 {
+				r.err = errs[0]
+			} else if i < len(errs) {
+				r.err = errs[i]
+			}
+			close(r.done)
+		}
+	}()
+
+	values, errs = l.fetch(ctx, b.keys)
+}
@@ -0,0 +1,461 @@
+package dataloader
+
+import (
+	"context"
+	"database/sql"
+	"github.com/andrewmthomas87/northwestern/database"
+	"github.com/andrewmthomas87/northwestern/models"
+	"time"
+)
+
+const (
+	defaultWait     = time.Millisecond
+	defaultMaxBatch = 500
+)
+
+type contextKey int
+
+const loadersContextKey contextKey = iota
+
+// Loaders batches the lookups GraphQL resolvers make once per parent object,
+// like a room's building, into a query per field instead of a query per row.
+type Loaders struct {
+	buildings         *Loader
+	rooms             *Loader
+	roomsByBuilding   *Loader
+	instructors       *Loader
+	schools           *Loader
+	subjects          *Loader
+	terms             *Loader
+	courses           *Loader
+	coursesByRoom     *Loader
+	coursesByBuilding *Loader
+	courseComponents  *Loader
+	courseAttributes  *Loader
+}
+
+func New(db *database.Database) *Loaders {
+	return &Loaders{
+		buildings:         NewLoader(buildingsBatch(db), defaultWait, defaultMaxBatch),
+		rooms:             NewLoader(roomsBatch(db), defaultWait, defaultMaxBatch),
+		roomsByBuilding:   NewLoader(roomsByBuildingBatch(db), defaultWait, defaultMaxBatch),
+		instructors:       NewLoader(instructorsBatch(db), defaultWait, defaultMaxBatch),
+		schools:           NewLoader(schoolsBatch(db), defaultWait, defaultMaxBatch),
+		subjects:          NewLoader(subjectsBatch(db), defaultWait, defaultMaxBatch),
+		terms:             NewLoader(termsBatch(db), defaultWait, defaultMaxBatch),
+		courses:           NewLoader(coursesBatch(db), defaultWait, defaultMaxBatch),
+		coursesByRoom:     NewLoader(coursesByRoomBatch(db), defaultWait, defaultMaxBatch),
+		coursesByBuilding: NewLoader(coursesByBuildingBatch(db), defaultWait, defaultMaxBatch),
+		courseComponents:  NewLoader(courseComponentsBatch(db), defaultWait, defaultMaxBatch),
+		courseAttributes:  NewLoader(courseAttributesBatch(db), defaultWait, defaultMaxBatch),
+	}
+}
+
+func WithLoaders(ctx context.Context, loaders *Loaders) context.Context {
+	return context.WithValue(ctx, loadersContextKey, loaders)
+}
+
+// For returns the loaders for the current request, or nil if none were
+// installed.
+func For(ctx context.Context) *Loaders {
+	loaders, _ := ctx.Value(loadersContextKey).(*Loaders)
+	return loaders
+}
+
+func intKeys(keys []interface{}) []int {
+	ids := make([]int, len(keys))
+	for i, key := range keys {
+		ids[i] = key.(int)
+	}
+
+	return ids
+}
+
+// byKey orders values found by a batch query to match keys. Keys without a
+// value get sql.ErrNoRows, like a single-row lookup would.
+func byKey(keys []interface{}, found map[interface{}]interface{}) ([]interface{}, []error) {
+	values := make([]interface{}, len(keys))
+	errs := make([]error, len(keys))
+	for i, key := range keys {
+		value, ok := found[key]
+		if !ok {
+			errs[i] = sql.ErrNoRows
+			continue
+		}
+		values[i] = value
+	}
+
+	return values, errs
+}
+
+func buildingsBatch(db *database.Database) BatchFunc {
+	return func(ctx context.Context, keys []interface{}) ([]interface{}, []error) {
+		buildings, err := db.SelectBuildingsByIds(ctx, intKeys(keys))
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		found := make(map[interface{}]interface{}, len(buildings))
+		for _, building := range buildings {
+			found[building.Id] = building
+		}
+
+		return byKey(keys, found)
+	}
+}
+
+func roomsBatch(db *database.Database) BatchFunc {
+	return func(ctx context.Context, keys []interface{}) ([]interface{}, []error) {
+		rooms, err := db.SelectRoomsByIds(ctx, intKeys(keys))
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		found := make(map[interface{}]interface{}, len(rooms))
+		for _, room := range rooms {
+			found[room.Id] = room
+		}
+
+		return byKey(keys, found)
+	}
+}
+
+func roomsByBuildingBatch(db *database.Database) BatchFunc {
+	return func(ctx context.Context, keys []interface{}) ([]interface{}, []error) {
+		rooms, err := db.SelectRoomsByBuildings(ctx, intKeys(keys))
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		grouped := make(map[int][]*models.Room, len(keys))
+		for _, room := range rooms {
+			grouped[room.BuildingId] = append(grouped[room.BuildingId], room)
+		}
+
+		values := make([]interface{}, len(keys))
+		for i, key := range keys {
+			values[i] = grouped[key.(int)]
+		}
+
+		return values, nil
+	}
+}
+
+func instructorsBatch(db *database.Database) BatchFunc {
+	return func(ctx context.Context, keys []interface{}) ([]interface{}, []error) {
+		instructors, err := db.SelectInstructorsByIds(ctx, intKeys(keys))
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		found := make(map[interface{}]interface{}, len(instructors))
+		for _, instructor := range instructors {
+			found[instructor.Id] = instructor
+		}
+
+		return byKey(keys, found)
+	}
+}
+
+func stringKeys(keys []interface{}) []string {
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		values[i] = key.(string)
+	}
+
+	return values
+}
+
+func schoolsBatch(db *database.Database) BatchFunc {
+	return func(ctx context.Context, keys []interface{}) ([]interface{}, []error) {
+		schools, err := db.SelectSchoolsBySymbols(ctx, stringKeys(keys))
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		found := make(map[interface{}]interface{}, len(schools))
+		for _, school := range schools {
+			found[school.Symbol] = school
+		}
+
+		return byKey(keys, found)
+	}
+}
+
+func subjectsBatch(db *database.Database) BatchFunc {
+	return func(ctx context.Context, keys []interface{}) ([]interface{}, []error) {
+		subjects, err := db.SelectSubjectsBySymbols(ctx, stringKeys(keys))
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		found := make(map[interface{}]interface{}, len(subjects))
+		for _, subject := range subjects {
+			found[subject.Symbol] = subject
+		}
+
+		return byKey(keys, found)
+	}
+}
+
+func termsBatch(db *database.Database) BatchFunc {
+	return func(ctx context.Context, keys []interface{}) ([]interface{}, []error) {
+		terms, err := db.SelectTermsByIds(ctx, intKeys(keys))
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		found := make(map[interface{}]interface{}, len(terms))
+		for _, term := range terms {
+			found[term.Id] = term
+		}
+
+		return byKey(keys, found)
+	}
+}
+
+func coursesBatch(db *database.Database) BatchFunc {
+	return func(ctx context.Context, keys []interface{}) ([]interface{}, []error) {
+		courses, err := db.SelectCoursesByIds(ctx, intKeys(keys))
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		found := make(map[interface{}]interface{}, len(courses))
+		for _, course := range courses {
+			found[course.Id] = course
+		}
+
+		return byKey(keys, found)
+	}
+}
+
+// termKey is a key for loaders that look things up within a term.
+type termKey struct {
+	term int
+	id   int
+}
+
+// byTerm groups keys by term, since each term is its own query.
+func byTerm(keys []interface{}) map[int][]int {
+	ids := make(map[int][]int)
+	for _, key := range keys {
+		k := key.(termKey)
+		ids[k.term] = append(ids[k.term], k.id)
+	}
+
+	return ids
+}
+
+func coursesByRoomBatch(db *database.Database) BatchFunc {
+	return func(ctx context.Context, keys []interface{}) ([]interface{}, []error) {
+		grouped := make(map[termKey][]*models.Course, len(keys))
+		for term, rooms := range byTerm(keys) {
+			courses, err := db.SelectCoursesByRooms(ctx, term, rooms)
+			if err != nil {
+				return nil, []error{err}
+			}
+			for _, course := range courses {
+				key := termKey{term: term, id: course.Room}
+				grouped[key] = append(grouped[key], course)
+			}
+		}
+
+		values := make([]interface{}, len(keys))
+		for i, key := range keys {
+			values[i] = grouped[key.(termKey)]
+		}
+
+		return values, nil
+	}
+}
+
+func coursesByBuildingBatch(db *database.Database) BatchFunc {
+	return func(ctx context.Context, keys []interface{}) ([]interface{}, []error) {
+		grouped := make(map[termKey][]*models.Course, len(keys))
+		for term, buildings := range byTerm(keys) {
+			courses, err := db.SelectCoursesByBuildings(ctx, term, buildings)
+			if err != nil {
+				return nil, []error{err}
+			}
+			for building, buildingCourses := range courses {
+				grouped[termKey{term: term, id: building}] = buildingCourses
+			}
+		}
+
+		values := make([]interface{}, len(keys))
+		for i, key := range keys {
+			values[i] = grouped[key.(termKey)]
+		}
+
+		return values, nil
+	}
+}
+
+func courseComponentsBatch(db *database.Database) BatchFunc {
+	return func(ctx context.Context, keys []interface{}) ([]interface{}, []error) {
+		courseComponents, err := db.SelectCourseComponentsByCourses(ctx, intKeys(keys))
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		grouped := make(map[int][]*models.CourseComponent, len(keys))
+		for _, courseComponent := range courseComponents {
+			grouped[courseComponent.Course] = append(grouped[courseComponent.Course], courseComponent)
+		}
+
+		values := make([]interface{}, len(keys))
+		for i, key := range keys {
+			values[i] = grouped[key.(int)]
+		}
+
+		return values, nil
+	}
+}
+
+func courseAttributesBatch(db *database.Database) BatchFunc {
+	return func(ctx context.Context, keys []interface{}) ([]interface{}, []error) {
+		attributes, err := db.SelectCourseAttributesByCourses(ctx, intKeys(keys))
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		values := make([]interface{}, len(keys))
+		for i, key := range keys {
+			values[i] = attributes[key.(int)]
+		}
+
+		return values, nil
+	}
+}
+
+func (l *Loaders) Building(ctx context.Context, id int) (*models.Building, error) {
+	value, err := l.buildings.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*models.Building), nil
+}
+
+func (l *Loaders) Room(ctx context.Context, id int) (*models.Room, error) {
+	value, err := l.rooms.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*models.Room), nil
+}
+
+func (l *Loaders) RoomsByBuilding(ctx context.Context, building int) ([]*models.Room, error) {
+	value, err := l.roomsByBuilding.Load(ctx, building)
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]*models.Room), nil
+}
+
+func (l *Loaders) Instructor(ctx context.Context, id int) (*models.Instructor, error) {
+	value, err := l.instructors.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*models.Instructor), nil
+}
+
+func (l *Loaders) School(ctx context.Context, symbol string) (*models.School, error) {
+	value, err := l.schools.Load(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*models.School), nil
+}
+
+func (l *Loaders) Subject(ctx context.Context, symbol string) (*models.Subject, error) {
+	value, err := l.subjects.Load(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*models.Subject), nil
+}
+
+func (l *Loaders) Term(ctx context.Context, id int) (*models.Term, error) {
+	value, err := l.terms.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*models.Term), nil
+}
+
+func (l *Loaders) Course(ctx context.Context, id int) (*models.Course, error) {
+	value, err := l.courses.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*models.Course), nil
+}
+
+// Node returns the loader for a node type's objects, keyed the way the type's
+// global ids are, or nil if the type isn't a node.
+func (l *Loaders) Node(typeName string) *Loader {
+	switch typeName {
+	case "Term":
+		return l.terms
+	case "School":
+		return l.schools
+	case "Subject":
+		return l.subjects
+	case "Instructor":
+		return l.instructors
+	case "Building":
+		return l.buildings
+	case "Room":
+		return l.rooms
+	case "Course":
+		return l.courses
+	default:
+		return nil
+	}
+}
+
+func (l *Loaders) CoursesByRoom(ctx context.Context, term int, room int) ([]*models.Course, error) {
+	value, err := l.coursesByRoom.Load(ctx, termKey{term: term, id: room})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]*models.Course), nil
+}
+
+func (l *Loaders) CoursesByBuilding(ctx context.Context, term int, building int) ([]*models.Course, error) {
+	value, err := l.coursesByBuilding.Load(ctx, termKey{term: term, id: building})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]*models.Course), nil
+}
+
+func (l *Loaders) CourseComponents(ctx context.Context, course int) ([]*models.CourseComponent, error) {
+	value, err := l.courseComponents.Load(ctx, course)
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]*models.CourseComponent), nil
+}
+
+func (l *Loaders) CourseAttributes(ctx context.Context, course int) ([]*models.Attribute, error) {
+	value, err := l.courseAttributes.Load(ctx, course)
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]*models.Attribute), nil
+}
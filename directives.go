@@ -0,0 +1,61 @@
+package northwestern
+
+import (
+	"context"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/andrewmthomas87/northwestern/apierror"
+	"github.com/andrewmthomas87/northwestern/models"
+	"github.com/andrewmthomas87/northwestern/server/auth"
+)
+
+var (
+	unauthenticatedError = apierror.New(apierror.Unauthenticated, "not signed in")
+	forbiddenError       = apierror.New(apierror.Forbidden, "not allowed")
+	apiKeyForbiddenError = apierror.New(apierror.Forbidden, "API keys can't manage API keys; sign in instead")
+	readOnlyAPIKeyError  = apierror.New(apierror.Forbidden, "read-only API keys can't run mutations")
+)
+
+// sessionEmail returns the signed in user's email, refusing requests made with
+// an API key so one key can't be used to mint or revoke others.
+func sessionEmail(ctx context.Context) (string, error) {
+	if _, ok := auth.APIKeyFromContext(ctx); ok {
+		return "", apiKeyForbiddenError
+	}
+
+	email, ok := auth.EmailFromContext(ctx)
+	if !ok {
+		return "", unauthenticatedError
+	}
+
+	return email, nil
+}
+
+// ReadOnlyMiddleware stops read-only API keys from running mutations.
+func ReadOnlyMiddleware(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	if readOnly, ok := auth.APIKeyFromContext(ctx); ok && readOnly {
+		if rctx := graphql.GetResolverContext(ctx); rctx != nil && rctx.Object == "Mutation" {
+			return nil, readOnlyAPIKeyError
+		}
+	}
+
+	return next(ctx)
+}
+
+// HasRole implements the @hasRole directive, looking the role up from the
+// email authHandler put in the request context.
+func (r *Resolver) HasRole(ctx context.Context, obj interface{}, next graphql.Resolver, role models.Role) (interface{}, error) {
+	email, ok := auth.EmailFromContext(ctx)
+	if !ok {
+		return nil, unauthenticatedError
+	}
+
+	hasRole, err := r.Db.HasRole(ctx, email, role)
+	if err != nil {
+		return nil, err
+	}
+	if !hasRole {
+		return nil, forbiddenError
+	}
+
+	return next(ctx)
+}
@@ -0,0 +1,33 @@
+package geo
+
+type Geometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+type Feature struct {
+	Type       string                 `json:"type"`
+	Id         interface{}            `json:"id,omitempty"`
+	Geometry   *Geometry              `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type FeatureCollection struct {
+	Type     string     `json:"type"`
+	Features []*Feature `json:"features"`
+}
+
+func NewFeatureCollection() *FeatureCollection {
+	return &FeatureCollection{Type: "FeatureCollection", Features: []*Feature{}}
+}
+
+// NewPointFeature creates a feature at a point. GeoJSON orders coordinates
+// longitude first.
+func NewPointFeature(id interface{}, lat, lon float64, properties map[string]interface{}) *Feature {
+	return &Feature{
+		Type:       "Feature",
+		Id:         id,
+		Geometry:   &Geometry{Type: "Point", Coordinates: []float64{lon, lat}},
+		Properties: properties,
+	}
+}
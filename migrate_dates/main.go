@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/andrewmthomas87/northwestern/database"
+	"github.com/spf13/viper"
+	"log"
+	"os"
+)
+
+// Converts a database created before dates and times were typed, parsing the
+// strings already stored. Values that don't parse are left NULL and listed; a
+// scrape or a data correction fills them back in. Term dates can't be NULL, so
+// if any of those don't parse nothing is converted until they're fixed.
+func main() {
+	ctx := context.Background()
+
+	viper.SetConfigName("config")
+	viper.AddConfigPath(".")
+	if err := viper.ReadInConfig(); err != nil {
+		panic(fmt.Errorf("Fatal error config file: %s \n", err))
+	}
+
+	db, err := database.NewDatabase(viper.GetString("database.user"), viper.GetString("database.password"), viper.GetString("database.host"), viper.GetInt("database.port"), viper.GetString("database.database"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	unparsed, err := db.MigrateDateColumns(ctx)
+	for _, value := range unparsed {
+		fmt.Fprintf(os.Stderr, "Unparsed: %s %s %s %q\n", value.Table, value.Key, value.Column, value.Value)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Migrated date and time columns, %d values couldn't be parsed\n", len(unparsed))
+}
@@ -1,10 +1,14 @@
 package models
 
+import (
+	"context"
+)
+
 type Term struct {
 	Id        int    `json:"id"`
 	Name      string `json:"name"`
-	StartDate string `json:"startDate"`
-	EndDate   string `json:"endDate"`
+	StartDate Date   `json:"startDate"`
+	EndDate   Date   `json:"endDate"`
 }
 
 type School struct {
@@ -47,6 +51,13 @@ type Room struct {
 	Name       string `json:"name"`
 }
 
+type RoomBooking struct {
+	Course    *Course `json:"course"`
+	Day       string  `json:"day"`
+	StartTime Time    `json:"startTime"`
+	EndTime   Time    `json:"endTime"`
+}
+
 type Course struct {
 	Id           int    `json:"id"`
 	Title        string `json:"title"`
@@ -58,10 +69,10 @@ type Course struct {
 	Section      string `json:"section"`
 	Room         int    `json:"room"`
 	MeetingDays  string `json:"meetingDays"`
-	StartTime    string `json:"startTime"`
-	EndTime      string `json:"endTime"`
-	StartDate    string `json:"startDate"`
-	EndDate      string `json:"endDate"`
+	StartTime    Time   `json:"startTime"`
+	EndTime      Time   `json:"endTime"`
+	StartDate    Date   `json:"startDate"`
+	EndDate      Date   `json:"endDate"`
 	Seats        int    `json:"seats"`
 	Overview     string `json:"overview"`
 	Topic        string `json:"topic"`
@@ -82,8 +93,159 @@ type CourseComponent struct {
 	Course      int    `json:"course"`
 	Component   string `json:"component"`
 	MeetingDays string `json:"meetingDays"`
-	StartTime   string `json:"startTime"`
-	EndTime     string `json:"endTime"`
+	StartTime   Time   `json:"startTime"`
+	EndTime     Time   `json:"endTime"`
 	Section     string `json:"section"`
 	Room        string `json:"room"`
 }
+
+type Attribute struct {
+	Symbol   string `json:"symbol"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+type CourseAttribute struct {
+	Course    int    `json:"course"`
+	Attribute string `json:"attribute"`
+}
+
+type CourseRequirement struct {
+	Course     int    `json:"course"`
+	Subject    string `json:"subject"`
+	CatalogNum string `json:"catalogNum"`
+}
+
+const (
+	CourseAdded   = "ADDED"
+	CourseUpdated = "UPDATED"
+	CourseRemoved = "REMOVED"
+)
+
+// CourseChange records what a scrape changed about a course. Fields holds the
+// GraphQL names of the fields that changed.
+type CourseChange struct {
+	ID            int      `json:"id"`
+	Course        int      `json:"course"`
+	Term          int      `json:"term"`
+	Subject       string   `json:"subject"`
+	Kind          string   `json:"kind"`
+	Fields        []string `json:"fields"`
+	PreviousSeats int      `json:"previousSeats"`
+	Seats         int      `json:"seats"`
+	CreatedAt     DateTime `json:"createdAt"`
+}
+
+// Notification tells a user watching a course what a scrape changed about it.
+type Notification struct {
+	ID            int      `json:"id"`
+	Email         string   `json:"email"`
+	Course        int      `json:"course"`
+	Kind          string   `json:"kind"`
+	Fields        []string `json:"fields"`
+	PreviousSeats int      `json:"previousSeats"`
+	Seats         int      `json:"seats"`
+	CreatedAt     DateTime `json:"createdAt"`
+	// Snapshot is the course as it was when it was removed, since it's deleted
+	// before the notification goes out.
+	Snapshot *Course `json:"snapshot"`
+}
+
+// WebhookEndpoint is where a webhook's deliveries go and the secret they're
+// signed with, which is never exposed through the API after it's created.
+type WebhookEndpoint struct {
+	ID     int
+	URL    string
+	Secret string
+}
+
+type Transition struct {
+	From             *Course `json:"from"`
+	To               *Course `json:"to"`
+	Day              string  `json:"day"`
+	Distance         float64 `json:"distance"`
+	WalkingMinutes   float64 `json:"walkingMinutes"`
+	AvailableMinutes int     `json:"availableMinutes"`
+	Tight            bool    `json:"tight"`
+}
+
+type ScheduleConflict struct {
+	First  *Course `json:"first"`
+	Second *Course `json:"second"`
+	Day    string  `json:"day"`
+	Soft   bool    `json:"soft"`
+}
+
+type Schedule struct {
+	Courses     []*Course           `json:"courses"`
+	Conflicts   []*ScheduleConflict `json:"conflicts"`
+	Transitions []*Transition       `json:"transitions"`
+}
+
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor"`
+	EndCursor       *string `json:"endCursor"`
+}
+
+// Connection holds what every connection type shares. Count only runs when a
+// client asks for totalCount.
+type Connection struct {
+	PageInfo *PageInfo                              `json:"pageInfo"`
+	Count    func(ctx context.Context) (int, error) `json:"-"`
+}
+
+func (c *Connection) TotalCount(ctx context.Context) (int, error) {
+	return c.Count(ctx)
+}
+
+type TermEdge struct {
+	Node   *Term  `json:"node"`
+	Cursor string `json:"cursor"`
+}
+
+type TermConnection struct {
+	Connection
+	Edges []*TermEdge `json:"edges"`
+}
+
+type SubjectEdge struct {
+	Node   *Subject `json:"node"`
+	Cursor string   `json:"cursor"`
+}
+
+type SubjectConnection struct {
+	Connection
+	Edges []*SubjectEdge `json:"edges"`
+}
+
+type BuildingEdge struct {
+	Node   *Building `json:"node"`
+	Cursor string    `json:"cursor"`
+}
+
+type BuildingConnection struct {
+	Connection
+	Edges []*BuildingEdge `json:"edges"`
+}
+
+type RoomEdge struct {
+	Node   *Room  `json:"node"`
+	Cursor string `json:"cursor"`
+}
+
+type RoomConnection struct {
+	Connection
+	Edges []*RoomEdge `json:"edges"`
+}
+
+type CourseEdge struct {
+	Node   *Course `json:"node"`
+	Cursor string  `json:"cursor"`
+}
+
+type CourseConnection struct {
+	Connection
+	Edges []*CourseEdge `json:"edges"`
+}
@@ -0,0 +1,446 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package models
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type Node interface {
+	IsNode()
+}
+
+type APIKey struct {
+	ID         int         `json:"id"`
+	Name       string      `json:"name"`
+	Prefix     string      `json:"prefix"`
+	Scope      APIKeyScope `json:"scope"`
+	CreatedAt  DateTime    `json:"createdAt"`
+	ExpiresAt  *DateTime   `json:"expiresAt"`
+	LastUsedAt *DateTime   `json:"lastUsedAt"`
+	Revoked    bool        `json:"revoked"`
+}
+
+type CreatedAPIKey struct {
+	APIKey *APIKey `json:"apiKey"`
+	Key    string  `json:"key"`
+}
+
+type CreatedWebhook struct {
+	Webhook *Webhook `json:"webhook"`
+	Secret  string   `json:"secret"`
+}
+
+type DataCorrection struct {
+	ID        int              `json:"id"`
+	Entity    CorrectionEntity `json:"entity"`
+	RecordID  int              `json:"recordId"`
+	Field     string           `json:"field"`
+	Value     string           `json:"value"`
+	CreatedBy string           `json:"createdBy"`
+	CreatedAt DateTime         `json:"createdAt"`
+}
+
+type LocationInput struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+type NotificationPreferences struct {
+	Channel    NotificationChannel   `json:"channel"`
+	Frequency  NotificationFrequency `json:"frequency"`
+	WebhookURL *string               `json:"webhookUrl"`
+}
+
+type ScrapeRequest struct {
+	ID          int          `json:"id"`
+	Term        *string      `json:"term"`
+	RequestedBy string       `json:"requestedBy"`
+	Status      ScrapeStatus `json:"status"`
+	Error       *string      `json:"error"`
+	CreatedAt   DateTime     `json:"createdAt"`
+	StartedAt   *DateTime    `json:"startedAt"`
+	FinishedAt  *DateTime    `json:"finishedAt"`
+}
+
+type SeatChange struct {
+	Course        *Course `json:"course"`
+	PreviousSeats int     `json:"previousSeats"`
+	Seats         int     `json:"seats"`
+}
+
+type User struct {
+	Email        string    `json:"email"`
+	Roles        []Role    `json:"roles"`
+	CreatedAt    DateTime  `json:"createdAt"`
+	LastSignInAt *DateTime `json:"lastSignInAt"`
+}
+
+type Webhook struct {
+	ID        int            `json:"id"`
+	URL       string         `json:"url"`
+	Events    []WebhookEvent `json:"events"`
+	Subjects  []string       `json:"subjects"`
+	CreatedBy string         `json:"createdBy"`
+	CreatedAt DateTime       `json:"createdAt"`
+}
+
+type WebhookDelivery struct {
+	ID             int                   `json:"id"`
+	WebhookID      int                   `json:"webhookId"`
+	Event          WebhookEvent          `json:"event"`
+	Payload        string                `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	LastStatusCode *int                  `json:"lastStatusCode"`
+	LastError      *string               `json:"lastError"`
+	CreatedAt      DateTime              `json:"createdAt"`
+	NextAttemptAt  *DateTime             `json:"nextAttemptAt"`
+	DeliveredAt    *DateTime             `json:"deliveredAt"`
+}
+
+type APIKeyScope string
+
+const (
+	APIKeyScopeRead      APIKeyScope = "READ"
+	APIKeyScopeReadWrite APIKeyScope = "READ_WRITE"
+)
+
+var AllAPIKeyScope = []APIKeyScope{
+	APIKeyScopeRead,
+	APIKeyScopeReadWrite,
+}
+
+func (e APIKeyScope) IsValid() bool {
+	switch e {
+	case APIKeyScopeRead, APIKeyScopeReadWrite:
+		return true
+	}
+	return false
+}
+
+func (e APIKeyScope) String() string {
+	return string(e)
+}
+
+func (e *APIKeyScope) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = APIKeyScope(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ApiKeyScope", str)
+	}
+	return nil
+}
+
+func (e APIKeyScope) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type CorrectionEntity string
+
+const (
+	CorrectionEntityCourse   CorrectionEntity = "COURSE"
+	CorrectionEntityBuilding CorrectionEntity = "BUILDING"
+	CorrectionEntityRoom     CorrectionEntity = "ROOM"
+)
+
+var AllCorrectionEntity = []CorrectionEntity{
+	CorrectionEntityCourse,
+	CorrectionEntityBuilding,
+	CorrectionEntityRoom,
+}
+
+func (e CorrectionEntity) IsValid() bool {
+	switch e {
+	case CorrectionEntityCourse, CorrectionEntityBuilding, CorrectionEntityRoom:
+		return true
+	}
+	return false
+}
+
+func (e CorrectionEntity) String() string {
+	return string(e)
+}
+
+func (e *CorrectionEntity) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = CorrectionEntity(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid CorrectionEntity", str)
+	}
+	return nil
+}
+
+func (e CorrectionEntity) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type NotificationChannel string
+
+const (
+	NotificationChannelEmail   NotificationChannel = "EMAIL"
+	NotificationChannelWebhook NotificationChannel = "WEBHOOK"
+)
+
+var AllNotificationChannel = []NotificationChannel{
+	NotificationChannelEmail,
+	NotificationChannelWebhook,
+}
+
+func (e NotificationChannel) IsValid() bool {
+	switch e {
+	case NotificationChannelEmail, NotificationChannelWebhook:
+		return true
+	}
+	return false
+}
+
+func (e NotificationChannel) String() string {
+	return string(e)
+}
+
+func (e *NotificationChannel) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = NotificationChannel(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid NotificationChannel", str)
+	}
+	return nil
+}
+
+func (e NotificationChannel) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type NotificationFrequency string
+
+const (
+	NotificationFrequencyImmediate NotificationFrequency = "IMMEDIATE"
+	NotificationFrequencyDigest    NotificationFrequency = "DIGEST"
+)
+
+var AllNotificationFrequency = []NotificationFrequency{
+	NotificationFrequencyImmediate,
+	NotificationFrequencyDigest,
+}
+
+func (e NotificationFrequency) IsValid() bool {
+	switch e {
+	case NotificationFrequencyImmediate, NotificationFrequencyDigest:
+		return true
+	}
+	return false
+}
+
+func (e NotificationFrequency) String() string {
+	return string(e)
+}
+
+func (e *NotificationFrequency) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = NotificationFrequency(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid NotificationFrequency", str)
+	}
+	return nil
+}
+
+func (e NotificationFrequency) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type Role string
+
+const (
+	RoleAdmin Role = "ADMIN"
+)
+
+var AllRole = []Role{
+	RoleAdmin,
+}
+
+func (e Role) IsValid() bool {
+	switch e {
+	case RoleAdmin:
+		return true
+	}
+	return false
+}
+
+func (e Role) String() string {
+	return string(e)
+}
+
+func (e *Role) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Role(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Role", str)
+	}
+	return nil
+}
+
+func (e Role) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ScrapeStatus string
+
+const (
+	ScrapeStatusPending   ScrapeStatus = "PENDING"
+	ScrapeStatusRunning   ScrapeStatus = "RUNNING"
+	ScrapeStatusSucceeded ScrapeStatus = "SUCCEEDED"
+	ScrapeStatusFailed    ScrapeStatus = "FAILED"
+)
+
+var AllScrapeStatus = []ScrapeStatus{
+	ScrapeStatusPending,
+	ScrapeStatusRunning,
+	ScrapeStatusSucceeded,
+	ScrapeStatusFailed,
+}
+
+func (e ScrapeStatus) IsValid() bool {
+	switch e {
+	case ScrapeStatusPending, ScrapeStatusRunning, ScrapeStatusSucceeded, ScrapeStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e ScrapeStatus) String() string {
+	return string(e)
+}
+
+func (e *ScrapeStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ScrapeStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ScrapeStatus", str)
+	}
+	return nil
+}
+
+func (e ScrapeStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "PENDING"
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "SUCCEEDED"
+	WebhookDeliveryStatusDead      WebhookDeliveryStatus = "DEAD"
+)
+
+var AllWebhookDeliveryStatus = []WebhookDeliveryStatus{
+	WebhookDeliveryStatusPending,
+	WebhookDeliveryStatusSucceeded,
+	WebhookDeliveryStatusDead,
+}
+
+func (e WebhookDeliveryStatus) IsValid() bool {
+	switch e {
+	case WebhookDeliveryStatusPending, WebhookDeliveryStatusSucceeded, WebhookDeliveryStatusDead:
+		return true
+	}
+	return false
+}
+
+func (e WebhookDeliveryStatus) String() string {
+	return string(e)
+}
+
+func (e *WebhookDeliveryStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = WebhookDeliveryStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid WebhookDeliveryStatus", str)
+	}
+	return nil
+}
+
+func (e WebhookDeliveryStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type WebhookEvent string
+
+const (
+	WebhookEventTermPublished    WebhookEvent = "TERM_PUBLISHED"
+	WebhookEventCourseAdded      WebhookEvent = "COURSE_ADDED"
+	WebhookEventCourseUpdated    WebhookEvent = "COURSE_UPDATED"
+	WebhookEventSectionCancelled WebhookEvent = "SECTION_CANCELLED"
+	WebhookEventRoomChanged      WebhookEvent = "ROOM_CHANGED"
+	WebhookEventSeatsChanged     WebhookEvent = "SEATS_CHANGED"
+	WebhookEventPing             WebhookEvent = "PING"
+)
+
+var AllWebhookEvent = []WebhookEvent{
+	WebhookEventTermPublished,
+	WebhookEventCourseAdded,
+	WebhookEventCourseUpdated,
+	WebhookEventSectionCancelled,
+	WebhookEventRoomChanged,
+	WebhookEventSeatsChanged,
+	WebhookEventPing,
+}
+
+func (e WebhookEvent) IsValid() bool {
+	switch e {
+	case WebhookEventTermPublished, WebhookEventCourseAdded, WebhookEventCourseUpdated, WebhookEventSectionCancelled, WebhookEventRoomChanged, WebhookEventSeatsChanged, WebhookEventPing:
+		return true
+	}
+	return false
+}
+
+func (e WebhookEvent) String() string {
+	return string(e)
+}
+
+func (e *WebhookEvent) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = WebhookEvent(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid WebhookEvent", str)
+	}
+	return nil
+}
+
+func (e WebhookEvent) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/andrewmthomas87/northwestern/apierror"
+	"strings"
+)
+
+var InvalidNodeIDError = apierror.New(apierror.InvalidArgument, "invalid node id")
+
+// NodeID builds the opaque, globally unique id of an object from its GraphQL
+// type and its key within that type.
+func NodeID(typeName string, key interface{}) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%v", typeName, key)))
+}
+
+// ParseNodeID splits a global id back into its type and key.
+func ParseNodeID(id string) (string, string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(id)
+	if err != nil {
+		return "", "", InvalidNodeIDError
+	}
+
+	parts := strings.SplitN(string(b), ":", 2)
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return "", "", InvalidNodeIDError
+	}
+
+	return parts[0], parts[1], nil
+}
+
+func (Term) IsNode()       {}
+func (School) IsNode()     {}
+func (Subject) IsNode()    {}
+func (Instructor) IsNode() {}
+func (Building) IsNode()   {}
+func (Room) IsNode()       {}
+func (Course) IsNode()     {}
+
+func (t *Term) ID() string       { return NodeID("Term", t.Id) }
+func (s *School) ID() string     { return NodeID("School", s.Symbol) }
+func (s *Subject) ID() string    { return NodeID("Subject", s.Symbol) }
+func (i *Instructor) ID() string { return NodeID("Instructor", i.Id) }
+func (b *Building) ID() string   { return NodeID("Building", b.Id) }
+func (r *Room) ID() string       { return NodeID("Room", r.Id) }
+func (c *Course) ID() string     { return NodeID("Course", c.Id) }
+
+func (t *Term) DatabaseID() int       { return t.Id }
+func (i *Instructor) DatabaseID() int { return i.Id }
+func (b *Building) DatabaseID() int   { return b.Id }
+func (r *Room) DatabaseID() int       { return r.Id }
+func (c *Course) DatabaseID() int     { return c.Id }
@@ -0,0 +1,284 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"github.com/andrewmthomas87/northwestern/apierror"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var dateLayouts = []string{"2006-01-02", "01/02/2006", "1/2/2006"}
+
+var timeLayouts = []string{"15:04", "15:04:05", "3:04PM", "3:04 PM", "3:04pm", "3:04 pm"}
+
+// MySQL hands back DATETIME columns in this layout unless the connection
+// parses times, which ours doesn't.
+const dateTimeLayout = "2006-01-02 15:04:05"
+
+// unknown reports whether upstream left a value out rather than sending one
+// we can't read. "TBA" is how it marks sections without a time yet.
+func unknown(s string) bool {
+	return len(s) == 0 || strings.EqualFold(s, "TBA")
+}
+
+func scanString(src interface{}) (string, bool, error) {
+	switch v := src.(type) {
+	case nil:
+		return "", false, nil
+	case []byte:
+		return string(v), true, nil
+	case string:
+		return v, true, nil
+	}
+	return "", false, fmt.Errorf("can't scan %T", src)
+}
+
+// Date is a day on the calendar with no time zone. The zero Date means the day
+// isn't known, and is null in the API and the database.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// ParseDate reads a date in any of the layouts upstream has used. Missing
+// dates parse to the zero Date.
+func ParseDate(s string) (Date, error) {
+	s = strings.TrimSpace(s)
+	if unknown(s) {
+		return Date{}, nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return Date{Year: t.Year(), Month: t.Month(), Day: t.Day()}, nil
+		}
+	}
+
+	return Date{}, fmt.Errorf("invalid date: %s", s)
+}
+
+func (d Date) IsZero() bool {
+	return d == Date{}
+}
+
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+func (d Date) MarshalGQL(w io.Writer) {
+	if d.IsZero() {
+		io.WriteString(w, "null")
+		return
+	}
+	io.WriteString(w, strconv.Quote(d.String()))
+}
+
+func (d *Date) UnmarshalGQL(v interface{}) error {
+	s, _ := v.(string)
+	date, err := ParseDate(s)
+	if err != nil || date.IsZero() {
+		return apierror.Errorf(apierror.InvalidArgument, "invalid date, expected YYYY-MM-DD: %v", v)
+	}
+
+	*d = date
+	return nil
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(b []byte) error {
+	var s *string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if s == nil {
+		*d = Date{}
+		return nil
+	}
+
+	var err error
+	*d, err = ParseDate(*s)
+	return err
+}
+
+func (d *Date) Scan(src interface{}) error {
+	s, ok, err := scanString(src)
+	if err != nil || !ok || s == "0000-00-00" {
+		*d = Date{}
+		return err
+	}
+
+	*d, err = ParseDate(s)
+	return err
+}
+
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+	return d.String(), nil
+}
+
+// Time is a time of day to the minute with no time zone, as minutes after
+// midnight. A Time that isn't Valid isn't known, and is null in the API and the
+// database.
+type Time struct {
+	Minutes int
+	Valid   bool
+}
+
+// ParseTime reads a time of day in 24 or 12 hour form. Missing times parse to
+// a Time that isn't Valid.
+func ParseTime(s string) (Time, error) {
+	s = strings.TrimSpace(s)
+	if unknown(s) {
+		return Time{}, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return Time{Minutes: t.Hour()*60 + t.Minute(), Valid: true}, nil
+		}
+	}
+
+	return Time{}, fmt.Errorf("invalid time: %s", s)
+}
+
+func (t Time) String() string {
+	if !t.Valid {
+		return ""
+	}
+	return fmt.Sprintf("%02d:%02d", t.Minutes/60, t.Minutes%60)
+}
+
+func (t Time) MarshalGQL(w io.Writer) {
+	if !t.Valid {
+		io.WriteString(w, "null")
+		return
+	}
+	io.WriteString(w, strconv.Quote(t.String()))
+}
+
+func (t *Time) UnmarshalGQL(v interface{}) error {
+	s, _ := v.(string)
+	parsed, err := ParseTime(s)
+	if err != nil || !parsed.Valid {
+		return apierror.Errorf(apierror.InvalidArgument, "invalid time, expected HH:MM: %v", v)
+	}
+
+	*t = parsed
+	return nil
+}
+
+func (t Time) MarshalJSON() ([]byte, error) {
+	if !t.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.String())
+}
+
+func (t *Time) UnmarshalJSON(b []byte) error {
+	var s *string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if s == nil {
+		*t = Time{}
+		return nil
+	}
+
+	var err error
+	*t, err = ParseTime(*s)
+	return err
+}
+
+func (t *Time) Scan(src interface{}) error {
+	s, ok, err := scanString(src)
+	if err != nil || !ok {
+		*t = Time{}
+		return err
+	}
+
+	*t, err = ParseTime(s)
+	return err
+}
+
+func (t Time) Value() (driver.Value, error) {
+	if !t.Valid {
+		return nil, nil
+	}
+	return t.String() + ":00", nil
+}
+
+// DateTime is an instant, stored in UTC and sent as RFC 3339.
+type DateTime struct {
+	time.Time
+}
+
+func NewDateTime(t time.Time) DateTime {
+	return DateTime{t.UTC()}
+}
+
+func (t DateTime) MarshalGQL(w io.Writer) {
+	if t.IsZero() {
+		io.WriteString(w, "null")
+		return
+	}
+	io.WriteString(w, strconv.Quote(t.UTC().Format(time.RFC3339)))
+}
+
+func (t *DateTime) UnmarshalGQL(v interface{}) error {
+	s, _ := v.(string)
+	parsed, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return apierror.Errorf(apierror.InvalidArgument, "invalid date time, expected RFC 3339: %v", v)
+	}
+
+	*t = NewDateTime(parsed)
+	return nil
+}
+
+func (t DateTime) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.UTC().Format(time.RFC3339))
+}
+
+func (t *DateTime) Scan(src interface{}) error {
+	if v, ok := src.(time.Time); ok {
+		*t = NewDateTime(v)
+		return nil
+	}
+
+	s, ok, err := scanString(src)
+	if err != nil || !ok {
+		*t = DateTime{}
+		return err
+	}
+
+	parsed, err := time.Parse(dateTimeLayout, s)
+	if err != nil {
+		return err
+	}
+	*t = DateTime{parsed}
+	return nil
+}
+
+func (t DateTime) Value() (driver.Value, error) {
+	if t.IsZero() {
+		return nil, nil
+	}
+	return t.UTC().Format(dateTimeLayout), nil
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Date
+		err  bool
+	}{
+		{in: "2019-09-24", want: Date{2019, time.September, 24}},
+		{in: "09/24/2019", want: Date{2019, time.September, 24}},
+		{in: "9/4/2019", want: Date{2019, time.September, 4}},
+		{in: " 2019-09-24 ", want: Date{2019, time.September, 24}},
+		{in: "", want: Date{}},
+		{in: "TBA", want: Date{}},
+		{in: "tba", want: Date{}},
+		{in: "September 24", err: true},
+		{in: "2019-13-01", err: true},
+	}
+	for _, test := range tests {
+		got, err := ParseDate(test.in)
+		if test.err {
+			if err == nil {
+				t.Errorf("ParseDate(%q) = %v, want an error", test.in, got)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("ParseDate(%q) = %v, %v, want %v", test.in, got, err, test.want)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Time
+		err  bool
+	}{
+		{in: "14:30", want: Time{Minutes: 870, Valid: true}},
+		{in: "09:05:00", want: Time{Minutes: 545, Valid: true}},
+		{in: "2:30PM", want: Time{Minutes: 870, Valid: true}},
+		{in: "2:30 PM", want: Time{Minutes: 870, Valid: true}},
+		{in: "12:00am", want: Time{Minutes: 0, Valid: true}},
+		{in: "9:05 am", want: Time{Minutes: 545, Valid: true}},
+		{in: "", want: Time{}},
+		{in: "TBA", want: Time{}},
+		{in: "noon", err: true},
+		{in: "25:00", err: true},
+	}
+	for _, test := range tests {
+		got, err := ParseTime(test.in)
+		if test.err {
+			if err == nil {
+				t.Errorf("ParseTime(%q) = %v, want an error", test.in, got)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("ParseTime(%q) = %v, %v, want %v", test.in, got, err, test.want)
+		}
+	}
+}
+
+func TestDateScan(t *testing.T) {
+	tests := []struct {
+		src  interface{}
+		want Date
+	}{
+		{src: []byte("2019-09-24"), want: Date{2019, time.September, 24}},
+		{src: "09/24/2019", want: Date{2019, time.September, 24}},
+		{src: "0000-00-00", want: Date{}},
+		{src: "TBA", want: Date{}},
+		{src: nil, want: Date{}},
+	}
+	for _, test := range tests {
+		got := Date{2000, time.January, 1}
+		if err := got.Scan(test.src); err != nil || got != test.want {
+			t.Errorf("Scan(%v) = %v, %v, want %v", test.src, got, err, test.want)
+		}
+	}
+
+	var d Date
+	if err := d.Scan(42); err == nil {
+		t.Error("Scan(42) succeeded")
+	}
+}
+
+func TestTimeScan(t *testing.T) {
+	tests := []struct {
+		src  interface{}
+		want Time
+	}{
+		{src: []byte("14:30:00"), want: Time{Minutes: 870, Valid: true}},
+		{src: "2:30 PM", want: Time{Minutes: 870, Valid: true}},
+		{src: "TBA", want: Time{}},
+		{src: nil, want: Time{}},
+	}
+	for _, test := range tests {
+		got := Time{Minutes: 1, Valid: true}
+		if err := got.Scan(test.src); err != nil || got != test.want {
+			t.Errorf("Scan(%v) = %v, %v, want %v", test.src, got, err, test.want)
+		}
+	}
+}
+
+func TestDateTimeScan(t *testing.T) {
+	var got DateTime
+	if err := got.Scan([]byte("2019-09-24 14:30:00")); err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2019, time.September, 24, 14, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Scan = %v, want %v", got, want)
+	}
+
+	if err := got.Scan(nil); err != nil || !got.IsZero() {
+		t.Errorf("Scan(nil) = %v, %v, want the zero DateTime", got, err)
+	}
+}
@@ -0,0 +1,95 @@
+package northwestern
+
+import (
+	"context"
+	"database/sql"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/andrewmthomas87/northwestern/dataloader"
+	"github.com/andrewmthomas87/northwestern/models"
+	"strconv"
+)
+
+// nodeKey splits a global id into its type's loader and the key that loader
+// takes: the symbol for schools and subjects, the numeric id for the rest.
+func nodeKey(loaders *dataloader.Loaders, id string) (*dataloader.Loader, interface{}, error) {
+	typeName, key, err := models.ParseNodeID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	loader := loaders.Node(typeName)
+	if loader == nil {
+		return nil, nil, models.InvalidNodeIDError
+	}
+
+	switch typeName {
+	case "School", "Subject":
+		return loader, key, nil
+	}
+
+	intKey, err := strconv.Atoi(key)
+	if err != nil {
+		return nil, nil, models.InvalidNodeIDError
+	}
+
+	return loader, intKey, nil
+}
+
+// found turns what a loader returned into a node, dropping missing rows so one
+// never becomes a non-nil interface holding a nil pointer.
+func found(value interface{}, err error) (models.Node, error) {
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return value.(models.Node), nil
+}
+
+func (r *queryResolver) Node(ctx context.Context, id string) (models.Node, error) {
+	loader, key, err := nodeKey(r.loaders(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+
+	return found(loader.Load(ctx, key))
+}
+
+// Nodes loads the ids of each type in one batch. An id that's invalid or fails
+// to load comes back null with its error reported, without failing the rest.
+func (r *queryResolver) Nodes(ctx context.Context, ids []string) ([]models.Node, error) {
+	loaders := r.loaders(ctx)
+
+	var order []*dataloader.Loader
+	keys := make(map[*dataloader.Loader][]interface{})
+	indexes := make(map[*dataloader.Loader][]int)
+	for i, id := range ids {
+		loader, key, err := nodeKey(loaders, id)
+		if err != nil {
+			graphql.AddError(ctx, err)
+			continue
+		}
+
+		if _, ok := keys[loader]; !ok {
+			order = append(order, loader)
+		}
+		keys[loader] = append(keys[loader], key)
+		indexes[loader] = append(indexes[loader], i)
+	}
+
+	nodes := make([]models.Node, len(ids))
+	for _, loader := range order {
+		values, errs := loader.LoadAll(ctx, keys[loader])
+		for j, i := range indexes[loader] {
+			node, err := found(values[j], errs[j])
+			if err != nil {
+				graphql.AddError(ctx, err)
+				continue
+			}
+			nodes[i] = node
+		}
+	}
+
+	return nodes, nil
+}
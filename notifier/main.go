@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/andrewmthomas87/northwestern/database"
+	"github.com/andrewmthomas87/northwestern/models"
+	"github.com/andrewmthomas87/northwestern/notify"
+	"github.com/andrewmthomas87/northwestern/webhooks"
+	"github.com/spf13/viper"
+	"log"
+	"time"
+)
+
+// Delivers the notifications the scraper queues for people watching courses,
+// and the deliveries it queues for webhooks.
+func main() {
+	ctx := context.Background()
+
+	viper.SetDefault("notify.interval", time.Minute)
+	viper.SetDefault("notify.digestInterval", 24*time.Hour)
+	viper.SetDefault("notify.webhook.timeout", 10*time.Second)
+	viper.SetDefault("notify.smtp.port", 587)
+	viper.SetDefault("notify.smtp.timeout", 30*time.Second)
+	viper.SetDefault("webhooks.interval", 15*time.Second)
+	viper.SetDefault("webhooks.timeout", 10*time.Second)
+
+	viper.SetConfigName("config")
+	viper.AddConfigPath(".")
+	if err := viper.ReadInConfig(); err != nil {
+		panic(fmt.Errorf("Fatal error config file: %s \n", err))
+	}
+
+	db, err := database.NewDatabase(viper.GetString("database.user"), viper.GetString("database.password"), viper.GetString("database.host"), viper.GetInt("database.port"), viper.GetString("database.database"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	notifiers := map[models.NotificationChannel]notify.Notifier{
+		models.NotificationChannelWebhook: notify.NewWebhookNotifier(notify.NewPublicClient(viper.GetDuration("notify.webhook.timeout"))),
+	}
+	if host := viper.GetString("notify.smtp.host"); len(host) > 0 {
+		notifiers[models.NotificationChannelEmail] = notify.NewEmailNotifier(host, viper.GetInt("notify.smtp.port"), viper.GetString("notify.smtp.username"), viper.GetString("notify.smtp.password"), viper.GetString("notify.smtp.from"), viper.GetDuration("notify.smtp.timeout"))
+	}
+
+	dispatcher := webhooks.NewDispatcher(db, notify.NewPublicClient(viper.GetDuration("webhooks.timeout")))
+	go dispatcher.Run(ctx, viper.GetDuration("webhooks.interval"))
+
+	deliverer := notify.NewDeliverer(db, notifiers, viper.GetDuration("notify.digestInterval"))
+	deliverer.Run(ctx, viper.GetDuration("notify.interval"))
+}
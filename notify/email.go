@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/andrewmthomas87/northwestern/models"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// EmailNotifier sends messages through an SMTP server. Leaving the username
+// empty skips authentication, which suits a local fake server in development.
+// Sending a message gives up after timeout, so a server that stops answering
+// can't hold up every other delivery.
+type EmailNotifier struct {
+	host    string
+	addr    string
+	auth    smtp.Auth
+	from    string
+	timeout time.Duration
+}
+
+func NewEmailNotifier(host string, port int, username string, password string, from string, timeout time.Duration) *EmailNotifier {
+	var auth smtp.Auth
+	if len(username) > 0 {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &EmailNotifier{host: host, addr: net.JoinHostPort(host, fmt.Sprint(port)), auth: auth, from: from, timeout: timeout}
+}
+
+// headerValue keeps scraped text from adding headers of its own.
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
+
+func (n *EmailNotifier) Notify(ctx context.Context, preferences *models.NotificationPreferences, message *Message) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue(n.from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue(message.Email))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", headerValue(message.Subject())))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.Replace(message.Body(), "\n", "\r\n", -1))
+
+	return n.send(ctx, message.Email, b.String())
+}
+
+// send does what smtp.SendMail does, but on a connection with a deadline that
+// is closed early if ctx is cancelled.
+func (n *EmailNotifier) send(ctx context.Context, to string, msg string) error {
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+
+	dialer := &net.Dialer{Timeout: n.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	c, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: n.host}); err != nil {
+			return err
+		}
+	}
+	if n.auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp server doesn't support AUTH")
+		}
+		if err := c.Auth(n.auth); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(n.from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte(msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
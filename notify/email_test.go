@@ -0,0 +1,140 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"github.com/andrewmthomas87/northwestern/models"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer accepts one connection and answers it just well enough for
+// net/smtp, sending what it received on data once the client quits. A silent
+// server accepts the connection and never says anything.
+func fakeSMTPServer(t *testing.T, silent bool) (string, int, <-chan string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	data := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if silent {
+			time.Sleep(time.Second)
+			return
+		}
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) {
+			conn.Write([]byte(line + "\r\n"))
+		}
+		reply("220 fake ESMTP")
+
+		var received strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250 fake")
+			case strings.HasPrefix(command, "MAIL"), strings.HasPrefix(command, "RCPT"):
+				received.WriteString(strings.TrimSpace(line) + "\n")
+				reply("250 OK")
+			case command == "DATA":
+				reply("354 go ahead")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					received.WriteString(line)
+				}
+				reply("250 OK")
+			case command == "QUIT":
+				reply("221 bye")
+				data <- received.String()
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return host, portNumber, data
+}
+
+func testMessage() *Message {
+	course := &models.Course{Subject: "COMP_SCI", CatalogNum: "211", Section: "20", Title: "Fundamentals of Computer Programming II"}
+	return &Message{Email: "someone@u.northwestern.edu", Updates: []*Update{{Course: course, PreviousSeats: 0, Seats: 3}}}
+}
+
+func TestEmailNotifierSends(t *testing.T) {
+	host, port, data := fakeSMTPServer(t, false)
+	notifier := NewEmailNotifier(host, port, "", "", "courses@northwestern.edu", time.Second)
+
+	if err := notifier.Notify(context.Background(), nil, testMessage()); err != nil {
+		t.Fatal(err)
+	}
+
+	received := <-data
+	for _, want := range []string{"MAIL FROM:<courses@northwestern.edu>", "RCPT TO:<someone@u.northwestern.edu>", "To: someone@u.northwestern.edu", "Seats opened in COMP_SCI 211-20"} {
+		if !strings.Contains(received, want) {
+			t.Errorf("server didn't receive %q in:\n%s", want, received)
+		}
+	}
+}
+
+func TestEmailNotifierTimesOut(t *testing.T) {
+	host, port, _ := fakeSMTPServer(t, true)
+	notifier := NewEmailNotifier(host, port, "", "", "courses@northwestern.edu", 100*time.Millisecond)
+
+	start := time.Now()
+	if err := notifier.Notify(context.Background(), nil, testMessage()); err == nil {
+		t.Fatal("sending to a silent server succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("sending took %v, want it to give up after the timeout", elapsed)
+	}
+}
+
+func TestEmailNotifierCancelled(t *testing.T) {
+	host, port, _ := fakeSMTPServer(t, true)
+	notifier := NewEmailNotifier(host, port, "", "", "courses@northwestern.edu", time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	if err := notifier.Notify(ctx, nil, testMessage()); err == nil {
+		t.Fatal("sending to a silent server succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("sending took %v, want it to stop when cancelled", elapsed)
+	}
+}
@@ -0,0 +1,197 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"github.com/andrewmthomas87/northwestern/models"
+	"log"
+	"strings"
+	"time"
+)
+
+// maxAttempts is how many times delivering a notification can fail before it's
+// given up on.
+const maxAttempts = 5
+
+// Update is one change to a course someone is watching.
+type Update struct {
+	ID            int            `json:"-"`
+	Course        *models.Course `json:"course"`
+	Fields        []string       `json:"fields"`
+	PreviousSeats int            `json:"previousSeats"`
+	Seats         int            `json:"seats"`
+	Removed       bool           `json:"removed"`
+}
+
+func (u *Update) SeatsOpened() bool {
+	return u.PreviousSeats <= 0 && u.Seats > 0
+}
+
+func (u *Update) Summary() string {
+	name := fmt.Sprintf("%s %s-%s %s", u.Course.Subject, u.Course.CatalogNum, u.Course.Section, u.Course.Title)
+	switch {
+	case u.Removed:
+		return fmt.Sprintf("%s was removed from the schedule", name)
+	case u.SeatsOpened():
+		return fmt.Sprintf("Seats opened in %s: %d available", name, u.Seats)
+	case u.PreviousSeats != u.Seats:
+		return fmt.Sprintf("Seats changed in %s: %d to %d", name, u.PreviousSeats, u.Seats)
+	default:
+		return fmt.Sprintf("%s changed: %s", name, strings.Join(u.Fields, ", "))
+	}
+}
+
+// Message is everything being delivered to one user at once: a single update
+// for immediate notifications, or everything since the last one for digests.
+type Message struct {
+	Email   string    `json:"email"`
+	Digest  bool      `json:"digest"`
+	Updates []*Update `json:"updates"`
+}
+
+func (m *Message) Subject() string {
+	if len(m.Updates) == 1 {
+		return m.Updates[0].Summary()
+	}
+	return fmt.Sprintf("%d updates to courses you're watching", len(m.Updates))
+}
+
+func (m *Message) Body() string {
+	var b strings.Builder
+	for _, update := range m.Updates {
+		b.WriteString(update.Summary())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Notifier delivers messages over one channel.
+type Notifier interface {
+	Notify(ctx context.Context, preferences *models.NotificationPreferences, message *Message) error
+}
+
+type Store interface {
+	SelectPendingNotifications(ctx context.Context, digestInterval time.Duration, maxAttempts int) ([]*models.Notification, map[string]*models.NotificationPreferences, error)
+	SelectCoursesByIds(ctx context.Context, ids []int) ([]*models.Course, error)
+	MarkNotificationsSent(ctx context.Context, ids []int) error
+	MarkNotificationsFailed(ctx context.Context, ids []int) error
+	UpdateNotificationDigestSent(ctx context.Context, email string) error
+}
+
+type Deliverer struct {
+	store          Store
+	notifiers      map[models.NotificationChannel]Notifier
+	digestInterval time.Duration
+}
+
+func NewDeliverer(store Store, notifiers map[models.NotificationChannel]Notifier, digestInterval time.Duration) *Deliverer {
+	return &Deliverer{store: store, notifiers: notifiers, digestInterval: digestInterval}
+}
+
+// Run delivers whatever is due every interval until ctx is done.
+func (d *Deliverer) Run(ctx context.Context, interval time.Duration) {
+	for {
+		if err := d.Deliver(ctx); err != nil {
+			log.Println(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Deliver sends every notification that's due. A user's failed delivery is
+// retried on a later pass and doesn't hold up anyone else's.
+func (d *Deliverer) Deliver(ctx context.Context) error {
+	notifications, preferences, err := d.store.SelectPendingNotifications(ctx, d.digestInterval, maxAttempts)
+	if err != nil {
+		return err
+	}
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	courseIds := make([]int, 0, len(notifications))
+	for _, notification := range notifications {
+		if notification.Snapshot == nil {
+			courseIds = append(courseIds, notification.Course)
+		}
+	}
+	courses, err := d.store.SelectCoursesByIds(ctx, courseIds)
+	if err != nil {
+		return err
+	}
+	coursesById := make(map[int]*models.Course, len(courses))
+	for _, course := range courses {
+		coursesById[course.Id] = course
+	}
+
+	var messages []*Message
+	var missing []int
+	byEmail := make(map[string]*Message)
+	for _, notification := range notifications {
+		course, ok := coursesById[notification.Course]
+		if notification.Snapshot != nil {
+			course, ok = notification.Snapshot, true
+		}
+		if !ok {
+			missing = append(missing, notification.ID)
+			continue
+		}
+		update := &Update{ID: notification.ID, Course: course, Fields: notification.Fields, PreviousSeats: notification.PreviousSeats, Seats: notification.Seats, Removed: notification.Kind == models.CourseRemoved}
+
+		if preferences[notification.Email].Frequency == models.NotificationFrequencyDigest {
+			message, ok := byEmail[notification.Email]
+			if !ok {
+				message = &Message{Email: notification.Email, Digest: true}
+				byEmail[notification.Email] = message
+				messages = append(messages, message)
+			}
+			message.Updates = append(message.Updates, update)
+		} else {
+			messages = append(messages, &Message{Email: notification.Email, Updates: []*Update{update}})
+		}
+	}
+
+	if err := d.store.MarkNotificationsFailed(ctx, missing); err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		if err := d.send(ctx, preferences[message.Email], message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Deliverer) send(ctx context.Context, preferences *models.NotificationPreferences, message *Message) error {
+	ids := make([]int, len(message.Updates))
+	for i, update := range message.Updates {
+		ids[i] = update.ID
+	}
+
+	notifier, ok := d.notifiers[preferences.Channel]
+	if !ok {
+		log.Printf("no notifier for %s, which %s wants notifications by", preferences.Channel, message.Email)
+		return d.store.MarkNotificationsFailed(ctx, ids)
+	}
+
+	if err := notifier.Notify(ctx, preferences, message); err != nil {
+		log.Printf("notifying %s: %s", message.Email, err)
+		return d.store.MarkNotificationsFailed(ctx, ids)
+	}
+
+	if err := d.store.MarkNotificationsSent(ctx, ids); err != nil {
+		return err
+	}
+	if message.Digest {
+		return d.store.UpdateNotificationDigestSent(ctx, message.Email)
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package northwestern
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/andrewmthomas87/northwestern/apierror"
+	"github.com/andrewmthomas87/northwestern/database"
+	"github.com/andrewmthomas87/northwestern/models"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+var invalidCursorError = apierror.New(apierror.InvalidArgument, "invalid cursor")
+
+// Cursors are opaque to clients but are just the row's sort key, tagged with
+// its type so a cursor for one list can't be passed to another.
+func encodeCursor(kind string, key interface{}) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%v", kind, key)))
+}
+
+func decodeCursor(kind string, cursor string) (string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", invalidCursorError
+	}
+
+	parts := strings.SplitN(string(b), ":", 2)
+	if len(parts) != 2 || parts[0] != kind {
+		return "", invalidCursorError
+	}
+
+	return parts[1], nil
+}
+
+func intKey(key string) (interface{}, error) {
+	id, err := strconv.Atoi(key)
+	if err != nil {
+		return nil, invalidCursorError
+	}
+
+	return id, nil
+}
+
+func stringKey(key string) (interface{}, error) {
+	return key, nil
+}
+
+// listLimit reads the limit argument of a list that isn't paginated. Limits are
+// capped at maxPageSize, which is what complexity.go charges for at most.
+func listLimit(limit *int, defaultLimit int) (int, error) {
+	if limit == nil {
+		return defaultLimit, nil
+	}
+	if *limit < 0 {
+		return 0, apierror.New(apierror.InvalidArgument, "limit can't be negative")
+	}
+	if *limit > maxPageSize {
+		return maxPageSize, nil
+	}
+
+	return *limit, nil
+}
+
+// newPage turns Relay's first/after/last/before arguments into a keyset page.
+func newPage(kind string, parseKey func(string) (interface{}, error), first *int, after *string, last *int, before *string) (*database.Page, error) {
+	if first != nil && last != nil {
+		return nil, apierror.New(apierror.InvalidArgument, "first and last can't be used together")
+	}
+
+	page := &database.Page{Limit: defaultPageSize}
+	if first != nil {
+		page.Limit = *first
+	} else if last != nil {
+		page.Limit = *last
+		page.Backward = true
+	}
+	if page.Limit < 0 || page.Limit > maxPageSize {
+		return nil, apierror.Errorf(apierror.InvalidArgument, "first and last must be between 0 and %d", maxPageSize)
+	}
+
+	if after != nil {
+		key, err := decodeCursor(kind, *after)
+		if err != nil {
+			return nil, err
+		}
+		if page.After, err = parseKey(key); err != nil {
+			return nil, err
+		}
+	}
+	if before != nil {
+		key, err := decodeCursor(kind, *before)
+		if err != nil {
+			return nil, err
+		}
+		if page.Before, err = parseKey(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
+// newPageInfo describes a page given the cursors of its first and last edges.
+// A page that starts after a cursor has at least that row before it, and one
+// that ends before a cursor has at least that row after it.
+func newPageInfo(page *database.Page, more bool, cursors []string) *models.PageInfo {
+	pageInfo := &models.PageInfo{
+		HasNextPage:     !page.Backward && more || page.Backward && page.Before != nil,
+		HasPreviousPage: page.Backward && more || !page.Backward && page.After != nil,
+	}
+	if len(cursors) > 0 {
+		pageInfo.StartCursor = &cursors[0]
+		pageInfo.EndCursor = &cursors[len(cursors)-1]
+	}
+
+	return pageInfo
+}
+
+// pageCourses applies page to a list of courses already loaded in id order,
+// the same way the database applies it to a query.
+func pageCourses(courses []*models.Course, page *database.Page) ([]*models.Course, bool) {
+	start, end := 0, len(courses)
+	if page.After != nil {
+		for start < end && courses[start].Id <= page.After.(int) {
+			start++
+		}
+	}
+	if page.Before != nil {
+		for end > start && courses[end-1].Id >= page.Before.(int) {
+			end--
+		}
+	}
+
+	more := end-start > page.Limit
+	if more && page.Backward {
+		start = end - page.Limit
+	} else if more {
+		end = start + page.Limit
+	}
+
+	return courses[start:end], more
+}
+
+func newCourseConnection(page *database.Page, courses []*models.Course, more bool, count func(ctx context.Context) (int, error)) *models.CourseConnection {
+	edges := make([]*models.CourseEdge, len(courses))
+	cursors := make([]string, len(courses))
+	for i, node := range courses {
+		cursors[i] = encodeCursor("course", node.Id)
+		edges[i] = &models.CourseEdge{Node: node, Cursor: cursors[i]}
+	}
+
+	return &models.CourseConnection{
+		Connection: models.Connection{PageInfo: newPageInfo(page, more, cursors), Count: count},
+		Edges:      edges,
+	}
+}
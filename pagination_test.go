@@ -0,0 +1,111 @@
+package northwestern
+
+import (
+	"github.com/andrewmthomas87/northwestern/database"
+	"github.com/andrewmthomas87/northwestern/models"
+	"reflect"
+	"testing"
+)
+
+func TestPageCourses(t *testing.T) {
+	var courses []*models.Course
+	for _, id := range []int{10, 20, 30, 40, 50} {
+		courses = append(courses, &models.Course{Id: id})
+	}
+
+	tests := []struct {
+		name            string
+		courses         []*models.Course
+		page            database.Page
+		want            []int
+		hasNextPage     bool
+		hasPreviousPage bool
+	}{
+		{name: "first", courses: courses, page: database.Page{Limit: 2}, want: []int{10, 20}, hasNextPage: true},
+		{name: "first after", courses: courses, page: database.Page{After: 20, Limit: 2}, want: []int{30, 40}, hasNextPage: true, hasPreviousPage: true},
+		{name: "first after to the end", courses: courses, page: database.Page{After: 30, Limit: 10}, want: []int{40, 50}, hasPreviousPage: true},
+		{name: "first between", courses: courses, page: database.Page{After: 10, Before: 50, Limit: 2}, want: []int{20, 30}, hasNextPage: true, hasPreviousPage: true},
+		{name: "last", courses: courses, page: database.Page{Limit: 2, Backward: true}, want: []int{40, 50}, hasPreviousPage: true},
+		{name: "last before", courses: courses, page: database.Page{Before: 40, Limit: 2, Backward: true}, want: []int{20, 30}, hasNextPage: true, hasPreviousPage: true},
+		{name: "last before to the start", courses: courses, page: database.Page{Before: 20, Limit: 10, Backward: true}, want: []int{10}, hasNextPage: true},
+		{name: "cursor between ids", courses: courses, page: database.Page{After: 25, Limit: 1}, want: []int{30}, hasNextPage: true, hasPreviousPage: true},
+		{name: "empty page past the end", courses: courses, page: database.Page{After: 50, Limit: 2}, want: []int{}, hasPreviousPage: true},
+		{name: "empty window", courses: courses, page: database.Page{After: 30, Before: 40, Limit: 2}, want: []int{}, hasPreviousPage: true},
+		{name: "zero limit", courses: courses, page: database.Page{Limit: 0}, want: []int{}, hasNextPage: true},
+		{name: "no courses", courses: nil, page: database.Page{Limit: 2}, want: []int{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			paged, more := pageCourses(test.courses, &test.page)
+
+			got := []int{}
+			for _, course := range paged {
+				got = append(got, course.Id)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("pageCourses = %v, want %v", got, test.want)
+			}
+
+			connection := newCourseConnection(&test.page, paged, more, nil)
+			pageInfo := connection.PageInfo
+			if pageInfo.HasNextPage != test.hasNextPage || pageInfo.HasPreviousPage != test.hasPreviousPage {
+				t.Errorf("hasNextPage, hasPreviousPage = %v, %v, want %v, %v", pageInfo.HasNextPage, pageInfo.HasPreviousPage, test.hasNextPage, test.hasPreviousPage)
+			}
+
+			if len(test.want) == 0 {
+				if pageInfo.StartCursor != nil || pageInfo.EndCursor != nil {
+					t.Errorf("an empty page has cursors %v, %v", *pageInfo.StartCursor, *pageInfo.EndCursor)
+				}
+				return
+			}
+			if start := encodeCursor("course", test.want[0]); pageInfo.StartCursor == nil || *pageInfo.StartCursor != start {
+				t.Errorf("startCursor = %v, want %s", pageInfo.StartCursor, start)
+			}
+			if end := encodeCursor("course", test.want[len(test.want)-1]); pageInfo.EndCursor == nil || *pageInfo.EndCursor != end {
+				t.Errorf("endCursor = %v, want %s", pageInfo.EndCursor, end)
+			}
+		})
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	intPtr := func(n int) *int { return &n }
+	cursor := func(id int) *string {
+		c := encodeCursor("course", id)
+		return &c
+	}
+	termCursor := encodeCursor("term", 1)
+
+	tests := []struct {
+		name   string
+		first  *int
+		after  *string
+		last   *int
+		before *string
+		want   database.Page
+		err    bool
+	}{
+		{name: "default", want: database.Page{Limit: defaultPageSize}},
+		{name: "first after", first: intPtr(5), after: cursor(20), want: database.Page{After: 20, Limit: 5}},
+		{name: "last before", last: intPtr(5), before: cursor(20), want: database.Page{Before: 20, Limit: 5, Backward: true}},
+		{name: "first and last", first: intPtr(5), last: intPtr(5), err: true},
+		{name: "negative", first: intPtr(-1), err: true},
+		{name: "too large", last: intPtr(maxPageSize + 1), err: true},
+		{name: "another list's cursor", after: &termCursor, err: true},
+		{name: "garbage cursor", before: func() *string { s := "!"; return &s }(), err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			page, err := newPage("course", intKey, test.first, test.after, test.last, test.before)
+			if test.err {
+				if err == nil {
+					t.Errorf("newPage = %+v, want an error", page)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(*page, test.want) {
+				t.Errorf("newPage = %+v, %v, want %+v", page, err, test.want)
+			}
+		})
+	}
+}
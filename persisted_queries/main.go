@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/andrewmthomas87/northwestern/database"
+	"github.com/andrewmthomas87/northwestern/generated"
+	"github.com/andrewmthomas87/northwestern/server/persisted"
+	"github.com/spf13/viper"
+	"github.com/vektah/gqlparser"
+	"io/ioutil"
+	"log"
+)
+
+// Registers the queries in the given files so they can run when the server only
+// accepts persisted queries. Each file holds one document, sent by clients
+// exactly as written, and the hash to send for it is printed.
+func main() {
+	ctx := context.Background()
+
+	flag.Parse()
+	if flag.NArg() == 0 {
+		log.Fatal("usage: persisted_queries file.graphql...")
+	}
+
+	viper.SetConfigName("config")
+	viper.AddConfigPath(".")
+	if err := viper.ReadInConfig(); err != nil {
+		panic(fmt.Errorf("Fatal error config file: %s \n", err))
+	}
+
+	db, err := database.NewDatabase(viper.GetString("database.user"), viper.GetString("database.password"), viper.GetString("database.host"), viper.GetInt("database.port"), viper.GetString("database.database"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	schema := generated.NewExecutableSchema(generated.Config{}).Schema()
+	for _, file := range flag.Args() {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		query := string(b)
+
+		if _, errs := gqlparser.LoadQuery(schema, query); errs != nil {
+			log.Fatalf("%s: %s", file, errs)
+		}
+
+		hash := persisted.Hash(query)
+		if err := db.InsertPersistedQuery(ctx, hash, query, true); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s  %s\n", hash, file)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/andrewmthomas87/northwestern/database"
+	"github.com/andrewmthomas87/northwestern/prerequisites"
+	"github.com/spf13/viper"
+	"log"
+	"os"
+)
+
+func main() {
+	ctx := context.Background()
+
+	subject := flag.String("subject", "", "subject to export the prerequisite graph of")
+	format := flag.String("format", prerequisites.FormatDOT, "output format (dot or json)")
+	output := flag.String("o", "", "file to write to instead of stdout")
+	flag.Parse()
+
+	if len(*subject) == 0 {
+		log.Fatal("-subject is required")
+	}
+
+	viper.SetConfigName("config")
+	viper.AddConfigPath(".")
+	if err := viper.ReadInConfig(); err != nil {
+		panic(fmt.Errorf("Fatal error config file: %s \n", err))
+	}
+
+	db, err := database.NewDatabase(viper.GetString("database.user"), viper.GetString("database.password"), viper.GetString("database.host"), viper.GetInt("database.port"), viper.GetString("database.database"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	graph, err := prerequisites.Load(ctx, db, *subject)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	out := os.Stdout
+	if len(*output) > 0 {
+		out, err = os.Create(*output)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer out.Close()
+	}
+
+	if err := graph.Write(out, *format); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, cycle := range graph.Cycles {
+		fmt.Fprintf(os.Stderr, "Cycle: %v\n", cycle)
+	}
+	for _, reference := range graph.Missing {
+		fmt.Fprintf(os.Stderr, "Missing: %s references %s %s\n", reference.From, reference.Subject, reference.CatalogNum)
+	}
+}
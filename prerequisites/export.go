@@ -0,0 +1,76 @@
+package prerequisites
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	FormatDOT  = "dot"
+	FormatJSON = "json"
+)
+
+func (g *Graph) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatDOT:
+		return g.WriteDOT(w)
+	case FormatJSON:
+		return g.WriteJSON(w)
+	default:
+		return fmt.Errorf("unknown format: %s", format)
+	}
+}
+
+func (g *Graph) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(g)
+}
+
+func (g *Graph) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	cycleEdges := make(map[Edge]bool)
+	for _, cycle := range g.Cycles {
+		for i := 0; i+1 < len(cycle); i++ {
+			cycleEdges[Edge{From: cycle[i], To: cycle[i+1]}] = true
+		}
+	}
+
+	fmt.Fprintf(bw, "digraph %s {\n", strconv.Quote(g.Subject))
+	fmt.Fprintf(bw, "  label=%s;\n", strconv.Quote(g.Name))
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	fmt.Fprintln(bw, "  node [shape=box];")
+	for _, node := range g.Nodes {
+		attributes := fmt.Sprintf("label=%s", strconv.Quote(node.Id+"\n"+node.Title))
+		if node.External {
+			attributes += ", style=dashed"
+		}
+		fmt.Fprintf(bw, "  %s [%s];\n", strconv.Quote(node.Id), attributes)
+	}
+	missing := make(map[string]bool)
+	for _, reference := range g.Missing {
+		id := nodeId(reference.Subject, reference.CatalogNum)
+		if missing[id] {
+			continue
+		}
+		missing[id] = true
+		fmt.Fprintf(bw, "  %s [label=%s, style=dotted, color=red];\n", strconv.Quote(id), strconv.Quote(id+"\n(not in catalog)"))
+	}
+	for _, edge := range g.Edges {
+		if cycleEdges[*edge] {
+			fmt.Fprintf(bw, "  %s -> %s [color=red];\n", strconv.Quote(edge.From), strconv.Quote(edge.To))
+		} else {
+			fmt.Fprintf(bw, "  %s -> %s;\n", strconv.Quote(edge.From), strconv.Quote(edge.To))
+		}
+	}
+	for _, reference := range g.Missing {
+		fmt.Fprintf(bw, "  %s -> %s [style=dotted, color=red];\n", strconv.Quote(nodeId(reference.Subject, reference.CatalogNum)), strconv.Quote(reference.From))
+	}
+	fmt.Fprintln(bw, "}")
+
+	return bw.Flush()
+}
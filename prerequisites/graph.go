@@ -0,0 +1,244 @@
+package prerequisites
+
+import (
+	"context"
+	"github.com/andrewmthomas87/northwestern/database"
+	"github.com/andrewmthomas87/northwestern/models"
+	"sort"
+	"strings"
+)
+
+type Node struct {
+	Id         string `json:"id"`
+	Subject    string `json:"subject"`
+	CatalogNum string `json:"catalogNum"`
+	Title      string `json:"title"`
+	External   bool   `json:"external"`
+}
+
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type MissingReference struct {
+	From       string `json:"from"`
+	Subject    string `json:"subject"`
+	CatalogNum string `json:"catalogNum"`
+}
+
+type Graph struct {
+	Subject string              `json:"subject"`
+	Name    string              `json:"name"`
+	Nodes   []*Node             `json:"nodes"`
+	Edges   []*Edge             `json:"edges"`
+	Cycles  [][]string          `json:"cycles"`
+	Missing []*MissingReference `json:"missing"`
+}
+
+func nodeId(subject, catalogNum string) string {
+	return subject + " " + catalogNum
+}
+
+// Load builds the prerequisite graph for a subject, pulling in the courses of
+// any other subjects its requirements reference.
+func Load(ctx context.Context, db *database.Database, symbol string) (*Graph, error) {
+	subject, err := db.SelectSubject(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	courses, err := db.SelectCoursesBySubjects(ctx, []string{symbol})
+	if err != nil {
+		return nil, err
+	}
+
+	requirements, err := db.SelectCourseRequirementsBySubject(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	var otherSubjects []string
+	seen := map[string]bool{symbol: true}
+	for _, requirement := range requirements {
+		if !seen[requirement.Subject] {
+			seen[requirement.Subject] = true
+			otherSubjects = append(otherSubjects, requirement.Subject)
+		}
+	}
+
+	if len(otherSubjects) > 0 {
+		otherCourses, err := db.SelectCoursesBySubjects(ctx, otherSubjects)
+		if err != nil {
+			return nil, err
+		}
+		courses = append(courses, otherCourses...)
+	}
+
+	return Build(subject, courses, requirements), nil
+}
+
+// Build assembles the graph for subject from every known course offering and
+// the parsed requirements of the subject's courses. Offerings are collapsed to
+// one node per catalog number, titled after the most recent term. Edges point
+// from the prerequisite to the course that requires it.
+func Build(subject *models.Subject, courses []*models.Course, requirements []*models.CourseRequirement) *Graph {
+	graph := &Graph{Subject: subject.Symbol, Name: subject.Name}
+
+	catalog := make(map[string]*models.Course)
+	courseNodes := make(map[int]string)
+	for _, course := range courses {
+		id := nodeId(course.Subject, course.CatalogNum)
+		courseNodes[course.Id] = id
+		if existing, ok := catalog[id]; !ok || course.Term > existing.Term {
+			catalog[id] = course
+		}
+	}
+
+	nodes := make(map[string]*Node)
+	addNode := func(id string) {
+		if _, ok := nodes[id]; ok {
+			return
+		}
+		course := catalog[id]
+		nodes[id] = &Node{
+			Id:         id,
+			Subject:    course.Subject,
+			CatalogNum: course.CatalogNum,
+			Title:      course.Title,
+			External:   course.Subject != subject.Symbol,
+		}
+	}
+	for id, course := range catalog {
+		if course.Subject == subject.Symbol {
+			addNode(id)
+		}
+	}
+
+	edges := make(map[Edge]bool)
+	missing := make(map[MissingReference]bool)
+	for _, requirement := range requirements {
+		to, ok := courseNodes[requirement.Course]
+		if !ok {
+			continue
+		}
+
+		from, ok := resolve(catalog, requirement.Subject, requirement.CatalogNum)
+		if !ok {
+			missing[MissingReference{From: to, Subject: requirement.Subject, CatalogNum: requirement.CatalogNum}] = true
+			continue
+		}
+		if from == to {
+			continue
+		}
+
+		addNode(from)
+		edges[Edge{From: from, To: to}] = true
+	}
+
+	for _, node := range nodes {
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].Id < graph.Nodes[j].Id })
+
+	for edge := range edges {
+		edge := edge
+		graph.Edges = append(graph.Edges, &edge)
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].From != graph.Edges[j].From {
+			return graph.Edges[i].From < graph.Edges[j].From
+		}
+		return graph.Edges[i].To < graph.Edges[j].To
+	})
+
+	for reference := range missing {
+		reference := reference
+		graph.Missing = append(graph.Missing, &reference)
+	}
+	sort.Slice(graph.Missing, func(i, j int) bool {
+		if graph.Missing[i].From != graph.Missing[j].From {
+			return graph.Missing[i].From < graph.Missing[j].From
+		}
+		return nodeId(graph.Missing[i].Subject, graph.Missing[i].CatalogNum) < nodeId(graph.Missing[j].Subject, graph.Missing[j].CatalogNum)
+	})
+
+	graph.Cycles = findCycles(graph.Nodes, graph.Edges)
+
+	return graph
+}
+
+// resolve matches a referenced catalog number against the catalog. Requirements
+// usually omit the "-0" suffix that single-quarter courses carry, and a bare
+// number can also stand for a whole sequence ("MATH 220" for "MATH 220-1").
+func resolve(catalog map[string]*models.Course, subject, catalogNum string) (string, bool) {
+	for _, candidate := range []string{catalogNum, catalogNum + "-0", catalogNum + "-1"} {
+		id := nodeId(subject, candidate)
+		if _, ok := catalog[id]; ok {
+			return id, true
+		}
+	}
+
+	if !strings.Contains(catalogNum, "-") {
+		var matches []string
+		for id := range catalog {
+			if strings.HasPrefix(id, nodeId(subject, catalogNum)+"-") {
+				matches = append(matches, id)
+			}
+		}
+		if len(matches) > 0 {
+			sort.Strings(matches)
+			return matches[0], true
+		}
+	}
+
+	return "", false
+}
+
+// findCycles runs a depth-first search and reports the cycle closed by every
+// back edge it finds, as the list of nodes along it.
+func findCycles(nodes []*Node, edges []*Edge) [][]string {
+	adjacent := make(map[string][]string)
+	for _, edge := range edges {
+		adjacent[edge.From] = append(adjacent[edge.From], edge.To)
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var stack []string
+	var cycles [][]string
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		stack = append(stack, id)
+		for _, next := range adjacent[id] {
+			switch state[next] {
+			case unvisited:
+				visit(next)
+			case visiting:
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == next {
+						cycle := append([]string{}, stack[i:]...)
+						cycles = append(cycles, append(cycle, next))
+						break
+					}
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = visited
+	}
+
+	for _, node := range nodes {
+		if state[node.Id] == unvisited {
+			visit(node.Id)
+		}
+	}
+
+	return cycles
+}
@@ -0,0 +1,37 @@
+package prerequisites
+
+import (
+	"github.com/andrewmthomas87/northwestern/models"
+	"regexp"
+)
+
+var referencePattern = regexp.MustCompile(`(?:\b([A-Z][A-Z_]+)\s+)?\b(\d{3}(?:-[0-9A-Z]+)?)\b`)
+
+// Parse extracts the courses referenced by a requirements string. Bare catalog
+// numbers ("COMP_SCI 211 or 213") belong to the most recently named subject,
+// starting with the subject of the course itself. Words that aren't known
+// subjects ("OR 213") don't change the current subject.
+func Parse(course *models.Course, subjects map[string]bool) []*models.CourseRequirement {
+	var requirements []*models.CourseRequirement
+	seen := make(map[string]bool)
+	subject := course.Subject
+	for _, match := range referencePattern.FindAllStringSubmatch(course.Requirements, -1) {
+		if len(match[1]) > 0 && subjects[match[1]] {
+			subject = match[1]
+		}
+
+		key := subject + " " + match[2]
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		requirements = append(requirements, &models.CourseRequirement{
+			Course:     course.Id,
+			Subject:    subject,
+			CatalogNum: match[2],
+		})
+	}
+
+	return requirements
+}
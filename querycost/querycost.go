@@ -0,0 +1,154 @@
+package querycost
+
+import (
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/ast"
+	"strings"
+)
+
+// maxComplexity caps complexity while walking so that list weights multiplied
+// down a deep query saturate instead of overflowing.
+const maxComplexity = 1 << 30
+
+// Analysis describes an operation before it runs. Paths are the response keys
+// leading to a field, so they line up with what the client wrote.
+type Analysis struct {
+	Depth      int
+	DeepestAt  []string
+	Complexity int
+	// CostliestAt is the deepest field that still accounts for most of the
+	// operation's complexity, or nil if no single top level field does.
+	CostliestAt []string
+}
+
+// FieldCosts overrides what a field costs on its own, keyed by "Type.field".
+// Fields not listed cost 1.
+type FieldCosts map[string]int
+
+func (costs FieldCosts) cost(object string, field string) int {
+	if cost, ok := costs[object+"."+field]; ok {
+		return cost
+	}
+	return 1
+}
+
+// Analyze walks op the way gqlgen's complexity package does, letting es weight
+// a field's children, but also tracks depth and where the cost comes from.
+// Introspection fields are free so tools like the playground keep working.
+func Analyze(es graphql.ExecutableSchema, op *ast.OperationDefinition, vars map[string]interface{}, costs FieldCosts) *Analysis {
+	w := &walker{es: es, schema: es.Schema(), vars: vars, costs: costs}
+	r := w.selectionSet(op.SelectionSet, nil)
+
+	return &Analysis{
+		Depth:       r.depth,
+		DeepestAt:   r.deepest,
+		Complexity:  r.complexity,
+		CostliestAt: r.costliest,
+	}
+}
+
+func Path(path []string) string {
+	return strings.Join(path, ".")
+}
+
+type walker struct {
+	es     graphql.ExecutableSchema
+	schema *ast.Schema
+	vars   map[string]interface{}
+	costs  FieldCosts
+}
+
+type result struct {
+	complexity int
+	depth      int
+	deepest    []string
+	costliest  []string
+}
+
+func (w *walker) selectionSet(set ast.SelectionSet, path []string) result {
+	var r result
+	var costliest int
+	for _, selection := range set {
+		var child result
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name, "__") {
+				continue
+			}
+			child = w.field(s, path)
+
+		case *ast.FragmentSpread:
+			child = w.selectionSet(s.Definition.SelectionSet, path)
+
+		case *ast.InlineFragment:
+			child = w.selectionSet(s.SelectionSet, path)
+		}
+
+		r.complexity = add(r.complexity, child.complexity)
+		if child.depth > r.depth {
+			r.depth = child.depth
+			r.deepest = child.deepest
+		}
+		if child.complexity > costliest {
+			costliest = child.complexity
+			r.costliest = child.costliest
+		}
+	}
+
+	// Only point at one selection if it dominates; otherwise the cost is
+	// spread across the set and the caller's field is the better answer.
+	if costliest*2 <= r.complexity {
+		r.costliest = nil
+	}
+
+	return r
+}
+
+func (w *walker) field(field *ast.Field, path []string) result {
+	fieldPath := make([]string, len(path), len(path)+1)
+	copy(fieldPath, path)
+	fieldPath = append(fieldPath, field.Alias)
+
+	var children result
+	switch w.schema.Types[field.Definition.Type.Name()].Kind {
+	case ast.Object, ast.Interface, ast.Union:
+		children = w.selectionSet(field.SelectionSet, fieldPath)
+	}
+
+	weighted := children.complexity
+	if object := field.ObjectDefinition; object.Kind != ast.Interface {
+		if complexity, ok := w.es.Complexity(object.Name, field.Name, children.complexity, field.ArgumentMap(w.vars)); ok {
+			weighted = complexity
+		}
+	}
+
+	cost := w.costs.cost(field.ObjectDefinition.Name, field.Name)
+	r := result{
+		complexity: add(cost, weighted),
+		depth:      children.depth + 1,
+		deepest:    children.deepest,
+		costliest:  children.costliest,
+	}
+	if r.deepest == nil {
+		r.deepest = fieldPath
+	}
+	// A plain scalar is never to blame; the list it's selected in is.
+	if r.costliest == nil && (weighted > 0 || cost > 1) {
+		r.costliest = fieldPath
+	}
+
+	return r
+}
+
+func add(a int, b int) int {
+	if a < 0 {
+		a = 0
+	}
+	if b < 0 {
+		b = 0
+	}
+	if a+b > maxComplexity {
+		return maxComplexity
+	}
+	return a + b
+}
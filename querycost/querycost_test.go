@@ -0,0 +1,160 @@
+package querycost
+
+import (
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser"
+	"github.com/vektah/gqlparser/ast"
+	"reflect"
+	"testing"
+)
+
+const testSchema = `
+type Query {
+    terms(first: Int): [Term!]!
+    term: Term
+}
+
+type Term {
+    id: Int!
+    name: String!
+    courses(first: Int): [Course!]!
+}
+
+type Course {
+    id: Int!
+    title: String!
+    term: Term!
+}
+`
+
+// testExecutableSchema weights list fields by their first argument, the way
+// the server's complexity functions weight pages.
+type testExecutableSchema struct {
+	graphql.ExecutableSchema
+	schema *ast.Schema
+}
+
+func (es *testExecutableSchema) Schema() *ast.Schema {
+	return es.schema
+}
+
+func (es *testExecutableSchema) Complexity(typeName string, fieldName string, childComplexity int, args map[string]interface{}) (int, bool) {
+	first, ok := args["first"].(int64)
+	if !ok {
+		return 0, false
+	}
+	return int(first) * childComplexity, true
+}
+
+func TestAnalyze(t *testing.T) {
+	schema, err := gqlparser.LoadSchema(&ast.Source{Input: testSchema})
+	if err != nil {
+		t.Fatal(err)
+	}
+	es := &testExecutableSchema{schema: schema}
+
+	tests := []struct {
+		name        string
+		query       string
+		costs       FieldCosts
+		complexity  int
+		depth       int
+		deepestAt   []string
+		costliestAt []string
+	}{
+		{
+			name:        "scalars",
+			query:       `{ term { id name } }`,
+			complexity:  3,
+			depth:       2,
+			deepestAt:   []string{"term", "id"},
+			costliestAt: []string{"term"},
+		},
+		{
+			name:        "nested lists multiply",
+			query:       `{ terms(first: 10) { courses(first: 5) { id title } } }`,
+			complexity:  111,
+			depth:       3,
+			deepestAt:   []string{"terms", "courses", "id"},
+			costliestAt: []string{"terms", "courses"},
+		},
+		{
+			name:        "saturates instead of overflowing",
+			query:       `{ terms(first: 1000000) { courses(first: 1000000) { term { courses(first: 1000000) { id } } } } }`,
+			complexity:  maxComplexity,
+			depth:       5,
+			deepestAt:   []string{"terms", "courses", "term", "courses", "id"},
+			costliestAt: []string{"terms", "courses", "term", "courses"},
+		},
+		{
+			name:        "fragment spreads",
+			query:       `query { terms(first: 2) { ...term } } fragment term on Term { id name }`,
+			complexity:  5,
+			depth:       2,
+			deepestAt:   []string{"terms", "id"},
+			costliestAt: []string{"terms"},
+		},
+		{
+			name:        "inline fragments",
+			query:       `{ terms(first: 2) { ... on Term { id } } }`,
+			complexity:  3,
+			depth:       2,
+			deepestAt:   []string{"terms", "id"},
+			costliestAt: []string{"terms"},
+		},
+		{
+			name:        "aliases",
+			query:       `{ t: term { c: courses(first: 1) { id } } }`,
+			complexity:  3,
+			depth:       3,
+			deepestAt:   []string{"t", "c", "id"},
+			costliestAt: []string{"t", "c"},
+		},
+		{
+			name:        "introspection is free",
+			query:       `{ __typename terms(first: 2) { __typename id } }`,
+			complexity:  3,
+			depth:       2,
+			deepestAt:   []string{"terms", "id"},
+			costliestAt: []string{"terms"},
+		},
+		{
+			name:       "cost spread evenly blames nothing",
+			query:      `{ a: terms(first: 10) { id } b: terms(first: 10) { id } }`,
+			complexity: 22,
+			depth:      2,
+			deepestAt:  []string{"a", "id"},
+		},
+		{
+			name:        "field costs",
+			query:       `{ term { id name } }`,
+			costs:       FieldCosts{"Term.name": 10},
+			complexity:  12,
+			depth:       2,
+			deepestAt:   []string{"term", "id"},
+			costliestAt: []string{"term", "name"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc, errs := gqlparser.LoadQuery(schema, test.query)
+			if errs != nil {
+				t.Fatal(errs)
+			}
+
+			analysis := Analyze(es, doc.Operations[0], nil, test.costs)
+			if analysis.Complexity != test.complexity {
+				t.Errorf("Complexity = %d, want %d", analysis.Complexity, test.complexity)
+			}
+			if analysis.Depth != test.depth {
+				t.Errorf("Depth = %d, want %d", analysis.Depth, test.depth)
+			}
+			if !reflect.DeepEqual(analysis.DeepestAt, test.deepestAt) {
+				t.Errorf("DeepestAt = %v, want %v", analysis.DeepestAt, test.deepestAt)
+			}
+			if !reflect.DeepEqual(analysis.CostliestAt, test.costliestAt) {
+				t.Errorf("CostliestAt = %v, want %v", analysis.CostliestAt, test.costliestAt)
+			}
+		})
+	}
+}
@@ -2,15 +2,48 @@ package northwestern
 
 import (
 	"context"
+	"database/sql"
+	"github.com/andrewmthomas87/northwestern/apierror"
+	"github.com/andrewmthomas87/northwestern/changes"
 	"github.com/andrewmthomas87/northwestern/database"
+	"github.com/andrewmthomas87/northwestern/dataloader"
 	"github.com/andrewmthomas87/northwestern/generated"
+	"github.com/andrewmthomas87/northwestern/geo"
 	"github.com/andrewmthomas87/northwestern/models"
+	"github.com/andrewmthomas87/northwestern/schedule"
+	"github.com/andrewmthomas87/northwestern/server/auth"
+	"github.com/andrewmthomas87/northwestern/webhooks"
+	"sort"
 )
 
 // THIS CODE IS A STARTING POINT ONLY. IT WILL NOT BE UPDATED WITH SCHEMA CHANGES.
 
 type Resolver struct {
-	Db *database.Database
+	Db       *database.Database
+	Changes  *changes.Broker
+	Webhooks *webhooks.Dispatcher
+}
+
+// loaders returns the request's dataloaders, falling back to unshared ones
+// when the handler didn't install any.
+func (r *Resolver) loaders(ctx context.Context) *dataloader.Loaders {
+	if loaders := dataloader.For(ctx); loaders != nil {
+		return loaders
+	}
+
+	return dataloader.New(r.Db)
+}
+
+func (r *Resolver) Building() generated.BuildingResolver {
+	return &buildingResolver{r}
+}
+
+func (r *Resolver) Course() generated.CourseResolver {
+	return &courseResolver{r}
+}
+
+func (r *Resolver) Mutation() generated.MutationResolver {
+	return &mutationResolver{r}
 }
 
 func (r *Resolver) Query() generated.QueryResolver {
@@ -21,15 +54,34 @@ func (r *Resolver) Room() generated.RoomResolver {
 	return &roomResolver{r}
 }
 
+func (r *Resolver) Subscription() generated.SubscriptionResolver {
+	return &subscriptionResolver{r}
+}
+
 type queryResolver struct{ *Resolver }
 
-func (r *queryResolver) Terms(ctx context.Context) ([]*models.Term, error) {
-	terms, err := r.Db.SelectAllTerms(ctx)
+func (r *queryResolver) Terms(ctx context.Context, first *int, after *string, last *int, before *string) (*models.TermConnection, error) {
+	page, err := newPage("term", intKey, first, after, last, before)
 	if err != nil {
 		return nil, err
 	}
 
-	return terms, nil
+	terms, more, err := r.Db.SelectTermsPage(ctx, page)
+	if err != nil {
+		return nil, err
+	}
+
+	edges := make([]*models.TermEdge, len(terms))
+	cursors := make([]string, len(terms))
+	for i, node := range terms {
+		cursors[i] = encodeCursor("term", node.Id)
+		edges[i] = &models.TermEdge{Node: node, Cursor: cursors[i]}
+	}
+
+	return &models.TermConnection{
+		Connection: models.Connection{PageInfo: newPageInfo(page, more, cursors), Count: r.Db.CountTerms},
+		Edges:      edges,
+	}, nil
 }
 
 func (r *queryResolver) Schools(ctx context.Context) ([]*models.School, error) {
@@ -41,13 +93,28 @@ func (r *queryResolver) Schools(ctx context.Context) ([]*models.School, error) {
 	return schools, nil
 }
 
-func (r *queryResolver) Subjects(ctx context.Context) ([]*models.Subject, error) {
-	subjects, err := r.Db.SelectAllSubjects(ctx)
+func (r *queryResolver) Subjects(ctx context.Context, first *int, after *string, last *int, before *string) (*models.SubjectConnection, error) {
+	page, err := newPage("subject", stringKey, first, after, last, before)
 	if err != nil {
 		return nil, err
 	}
 
-	return subjects, nil
+	subjects, more, err := r.Db.SelectSubjectsPage(ctx, page)
+	if err != nil {
+		return nil, err
+	}
+
+	edges := make([]*models.SubjectEdge, len(subjects))
+	cursors := make([]string, len(subjects))
+	for i, node := range subjects {
+		cursors[i] = encodeCursor("subject", node.Symbol)
+		edges[i] = &models.SubjectEdge{Node: node, Cursor: cursors[i]}
+	}
+
+	return &models.SubjectConnection{
+		Connection: models.Connection{PageInfo: newPageInfo(page, more, cursors), Count: r.Db.CountSubjects},
+		Edges:      edges,
+	}, nil
 }
 
 func (r *queryResolver) SubjectsByTerm(ctx context.Context, term int) ([]*models.Subject, error) {
@@ -59,22 +126,52 @@ func (r *queryResolver) SubjectsByTerm(ctx context.Context, term int) ([]*models
 	return subjects, nil
 }
 
-func (r *queryResolver) Buildings(ctx context.Context) ([]*models.Building, error) {
-	buildings, err := r.Db.SelectAllBuildings(ctx)
+func (r *queryResolver) Buildings(ctx context.Context, first *int, after *string, last *int, before *string) (*models.BuildingConnection, error) {
+	page, err := newPage("building", intKey, first, after, last, before)
 	if err != nil {
 		return nil, err
 	}
 
-	return buildings, nil
+	buildings, more, err := r.Db.SelectBuildingsPage(ctx, page)
+	if err != nil {
+		return nil, err
+	}
+
+	edges := make([]*models.BuildingEdge, len(buildings))
+	cursors := make([]string, len(buildings))
+	for i, node := range buildings {
+		cursors[i] = encodeCursor("building", node.Id)
+		edges[i] = &models.BuildingEdge{Node: node, Cursor: cursors[i]}
+	}
+
+	return &models.BuildingConnection{
+		Connection: models.Connection{PageInfo: newPageInfo(page, more, cursors), Count: r.Db.CountBuildings},
+		Edges:      edges,
+	}, nil
 }
 
-func (r *queryResolver) Rooms(ctx context.Context) ([]*models.Room, error) {
-	rooms, err := r.Db.SelectAllRooms(ctx)
+func (r *queryResolver) Rooms(ctx context.Context, first *int, after *string, last *int, before *string) (*models.RoomConnection, error) {
+	page, err := newPage("room", intKey, first, after, last, before)
 	if err != nil {
 		return nil, err
 	}
 
-	return rooms, nil
+	rooms, more, err := r.Db.SelectRoomsPage(ctx, page)
+	if err != nil {
+		return nil, err
+	}
+
+	edges := make([]*models.RoomEdge, len(rooms))
+	cursors := make([]string, len(rooms))
+	for i, node := range rooms {
+		cursors[i] = encodeCursor("room", node.Id)
+		edges[i] = &models.RoomEdge{Node: node, Cursor: cursors[i]}
+	}
+
+	return &models.RoomConnection{
+		Connection: models.Connection{PageInfo: newPageInfo(page, more, cursors), Count: r.Db.CountRooms},
+		Edges:      edges,
+	}, nil
 }
 
 func (r *queryResolver) RoomsByBuilding(ctx context.Context, building int) ([]*models.Room, error) {
@@ -86,13 +183,465 @@ func (r *queryResolver) RoomsByBuilding(ctx context.Context, building int) ([]*m
 	return rooms, nil
 }
 
+func (r *queryResolver) FreeRooms(ctx context.Context, term int, day string, start models.Time, end models.Time, building *int, near *models.LocationInput) ([]*models.Room, error) {
+	weekday, err := schedule.ParseDay(day)
+	if err != nil {
+		return nil, err
+	}
+	if end.Minutes <= start.Minutes {
+		return nil, apierror.New(apierror.InvalidArgument, "end must be after start")
+	}
+
+	courses, err := r.Db.SelectCourses(ctx, term, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	occupied := schedule.OccupiedRooms(courses, weekday, start.Minutes, end.Minutes)
+
+	var rooms []*models.Room
+	if building != nil {
+		rooms, err = r.Db.SelectRoomsByBuilding(ctx, *building)
+	} else {
+		rooms, err = r.Db.SelectAllRooms(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	freeRooms := make([]*models.Room, 0, len(rooms))
+	for _, room := range rooms {
+		if !occupied[room.Id] {
+			freeRooms = append(freeRooms, room)
+		}
+	}
+
+	if near != nil {
+		buildings, err := r.Db.SelectAllBuildings(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		distances := make(map[int]float64, len(buildings))
+		for _, building := range buildings {
+			distances[building.Id] = geo.Distance(near.Lat, near.Lon, building.Lat, building.Lon)
+		}
+
+		sort.SliceStable(freeRooms, func(i, j int) bool {
+			di, ok := distances[freeRooms[i].BuildingId]
+			if !ok {
+				return false
+			}
+			dj, ok := distances[freeRooms[j].BuildingId]
+			if !ok {
+				return true
+			}
+			return di < dj
+		})
+	}
+
+	return freeRooms, nil
+}
+
+func (r *queryResolver) Attributes(ctx context.Context) ([]*models.Attribute, error) {
+	attributes, err := r.Db.SelectAllAttributes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return attributes, nil
+}
+
+func (r *queryResolver) Courses(ctx context.Context, term int, subject *string, attribute *string, first *int, after *string, last *int, before *string) (*models.CourseConnection, error) {
+	page, err := newPage("course", intKey, first, after, last, before)
+	if err != nil {
+		return nil, err
+	}
+
+	courses, more, err := r.Db.SelectCoursesPage(ctx, term, subject, attribute, page)
+	if err != nil {
+		return nil, err
+	}
+
+	count := func(ctx context.Context) (int, error) {
+		return r.Db.CountCourses(ctx, term, subject, attribute)
+	}
+
+	return newCourseConnection(page, courses, more, count), nil
+}
+
+func (r *queryResolver) CoursesByAttribute(ctx context.Context, term int, attribute string, first *int, after *string, last *int, before *string) (*models.CourseConnection, error) {
+	return r.Courses(ctx, term, nil, &attribute, first, after, last, before)
+}
+
+func (r *queryResolver) Schedule(ctx context.Context, courses []int, tightTransitionsAsConflicts *bool) (*models.Schedule, error) {
+	selectedCourses, err := r.Db.SelectCoursesByIds(ctx, courses)
+	if err != nil {
+		return nil, err
+	}
+
+	roomIds := make([]int, 0, len(selectedCourses))
+	for _, course := range selectedCourses {
+		roomIds = append(roomIds, course.Room)
+	}
+	rooms, err := r.Db.SelectRoomsByIds(ctx, roomIds)
+	if err != nil {
+		return nil, err
+	}
+
+	buildingIds := make([]int, 0, len(rooms))
+	for _, room := range rooms {
+		buildingIds = append(buildingIds, room.BuildingId)
+	}
+	buildings, err := r.Db.SelectBuildingsByIds(ctx, buildingIds)
+	if err != nil {
+		return nil, err
+	}
+
+	buildingsMap := make(map[int]*models.Building, len(buildings))
+	for _, building := range buildings {
+		buildingsMap[building.Id] = building
+	}
+	roomBuildings := make(map[int]*models.Building, len(rooms))
+	for _, room := range rooms {
+		if building, ok := buildingsMap[room.BuildingId]; ok {
+			roomBuildings[room.Id] = building
+		}
+	}
+
+	var meetings []*schedule.Meeting
+	for _, course := range selectedCourses {
+		meetings = append(meetings, schedule.Meetings(course)...)
+	}
+	transitions := schedule.Transitions(meetings, roomBuildings)
+	conflicts := schedule.Conflicts(meetings, transitions, tightTransitionsAsConflicts != nil && *tightTransitionsAsConflicts)
+
+	result := &models.Schedule{
+		Courses:     selectedCourses,
+		Conflicts:   make([]*models.ScheduleConflict, len(conflicts)),
+		Transitions: make([]*models.Transition, len(transitions)),
+	}
+	for i, conflict := range conflicts {
+		result.Conflicts[i] = &models.ScheduleConflict{
+			First:  conflict.First.Course,
+			Second: conflict.Second.Course,
+			Day:    schedule.FormatDay(conflict.First.Day),
+			Soft:   conflict.Soft,
+		}
+	}
+	for i, transition := range transitions {
+		result.Transitions[i] = &models.Transition{
+			From:             transition.From.Course,
+			To:               transition.To.Course,
+			Day:              schedule.FormatDay(transition.From.Day),
+			Distance:         transition.Distance,
+			WalkingMinutes:   transition.WalkingMinutes,
+			AvailableMinutes: transition.AvailableMinutes,
+			Tight:            transition.Tight,
+		}
+	}
+
+	return result, nil
+}
+
+func (r *queryResolver) Me(ctx context.Context) (*models.User, error) {
+	email, ok := auth.EmailFromContext(ctx)
+	if !ok {
+		return nil, unauthenticatedError
+	}
+
+	user, err := r.Db.SelectUser(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *queryResolver) APIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	email, err := sessionEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys, err := r.Db.SelectAPIKeysByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	return apiKeys, nil
+}
+
+func (r *queryResolver) Users(ctx context.Context) ([]*models.User, error) {
+	users, err := r.Db.SelectAllUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (r *queryResolver) AllowedUsers(ctx context.Context) ([]string, error) {
+	emails, err := r.Db.SelectInvitedUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return emails, nil
+}
+
+func (r *queryResolver) DeniedUsers(ctx context.Context) ([]string, error) {
+	emails, err := r.Db.SelectDeniedUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return emails, nil
+}
+
+const defaultScrapeRequestsLimit = 20
+
+func (r *queryResolver) ScrapeRequests(ctx context.Context, limit *int) ([]*models.ScrapeRequest, error) {
+	n, err := listLimit(limit, defaultScrapeRequestsLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	requests, err := r.Db.SelectScrapeRequests(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+func (r *queryResolver) DataCorrections(ctx context.Context) ([]*models.DataCorrection, error) {
+	corrections, err := r.Db.SelectAllDataCorrections(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return corrections, nil
+}
+
+type mutationResolver struct{ *Resolver }
+
+func (r *mutationResolver) CreateAPIKey(ctx context.Context, name string, scope models.APIKeyScope, expiresInDays *int) (*models.CreatedAPIKey, error) {
+	email, err := sessionEmail(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if expiresInDays != nil && *expiresInDays < 1 {
+		return nil, apierror.New(apierror.InvalidArgument, "expiresInDays must be at least 1")
+	}
+
+	key, keyHash, prefix, err := auth.NewAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey, err := r.Db.InsertAPIKey(ctx, email, name, prefix, keyHash, scope, expiresInDays)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+func (r *mutationResolver) RevokeAPIKey(ctx context.Context, id int) (bool, error) {
+	email, err := sessionEmail(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return r.Db.RevokeAPIKey(ctx, email, id)
+}
+
+func (r *mutationResolver) GrantRole(ctx context.Context, email string, role models.Role) (*models.User, error) {
+	if err := r.Db.GrantRole(ctx, email, role); err != nil {
+		return nil, err
+	}
+
+	return r.Db.SelectUser(ctx, email)
+}
+
+func (r *mutationResolver) RevokeRole(ctx context.Context, email string, role models.Role) (*models.User, error) {
+	if current, _ := auth.EmailFromContext(ctx); current == email && role == models.RoleAdmin {
+		return nil, apierror.New(apierror.Forbidden, "admins can't revoke their own admin role")
+	}
+
+	if err := r.Db.RevokeRole(ctx, email, role); err != nil {
+		return nil, err
+	}
+
+	return r.Db.SelectUser(ctx, email)
+}
+
+func (r *mutationResolver) AllowUser(ctx context.Context, email string) (bool, error) {
+	if err := r.Db.AllowUser(ctx, email); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *mutationResolver) DisallowUser(ctx context.Context, email string) (bool, error) {
+	if err := r.Db.DisallowUser(ctx, email); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *mutationResolver) CreateInviteCode(ctx context.Context, code string, maxUses int, expiresAt *models.DateTime) (bool, error) {
+	if maxUses < 1 {
+		return false, apierror.New(apierror.InvalidArgument, "maxUses must be at least 1")
+	}
+
+	if err := r.Db.InsertInviteCode(ctx, code, maxUses, expiresAt); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *mutationResolver) RequestScrape(ctx context.Context, term *string) (*models.ScrapeRequest, error) {
+	email, _ := auth.EmailFromContext(ctx)
+
+	if term != nil {
+		if _, err := r.Db.SelectTermByName(ctx, *term); err != nil {
+			return nil, err
+		}
+	}
+
+	request, err := r.Db.InsertScrapeRequest(ctx, term, email)
+	if err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+func (r *mutationResolver) AddDataCorrection(ctx context.Context, entity models.CorrectionEntity, recordID int, field string, value string) (*models.DataCorrection, error) {
+	email, _ := auth.EmailFromContext(ctx)
+
+	correction, err := r.Db.InsertDataCorrection(ctx, entity, recordID, field, value, email)
+	if err != nil {
+		return nil, err
+	}
+
+	return correction, nil
+}
+
+func (r *mutationResolver) DeleteDataCorrection(ctx context.Context, id int) (bool, error) {
+	return r.Db.DeleteDataCorrection(ctx, id)
+}
+
+type buildingResolver struct{ *Resolver }
+
+func (r *buildingResolver) Rooms(ctx context.Context, obj *models.Building) ([]*models.Room, error) {
+	rooms, err := r.loaders(ctx).RoomsByBuilding(ctx, obj.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return rooms, nil
+}
+
+// Courses pages through the building's courses after loading all of them, so
+// the lookup batches with every other building in the request.
+func (r *buildingResolver) Courses(ctx context.Context, obj *models.Building, term int, first *int, after *string, last *int, before *string) (*models.CourseConnection, error) {
+	page, err := newPage("course", intKey, first, after, last, before)
+	if err != nil {
+		return nil, err
+	}
+
+	courses, err := r.loaders(ctx).CoursesByBuilding(ctx, term, obj.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	paged, more := pageCourses(courses, page)
+	count := func(ctx context.Context) (int, error) {
+		return len(courses), nil
+	}
+
+	return newCourseConnection(page, paged, more, count), nil
+}
+
+type courseResolver struct{ *Resolver }
+
+func (r *courseResolver) Instructor(ctx context.Context, obj *models.Course) (*models.Instructor, error) {
+	instructor, err := r.loaders(ctx).Instructor(ctx, obj.Instructor)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return instructor, nil
+}
+
+func (r *courseResolver) Room(ctx context.Context, obj *models.Course) (*models.Room, error) {
+	room, err := r.loaders(ctx).Room(ctx, obj.Room)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return room, nil
+}
+
+func (r *courseResolver) Attributes(ctx context.Context, obj *models.Course) ([]*models.Attribute, error) {
+	attributes, err := r.loaders(ctx).CourseAttributes(ctx, obj.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return attributes, nil
+}
+
+func (r *courseResolver) Components(ctx context.Context, obj *models.Course) ([]*models.CourseComponent, error) {
+	courseComponents, err := r.loaders(ctx).CourseComponents(ctx, obj.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return courseComponents, nil
+}
+
 type roomResolver struct{ *Resolver }
 
 func (r *roomResolver) Building(ctx context.Context, obj *models.Room) (*models.Building, error) {
-	building, err := r.Db.SelectBuilding(ctx, obj.BuildingId)
+	building, err := r.loaders(ctx).Building(ctx, obj.BuildingId)
 	if err != nil {
 		return nil, err
 	}
 
 	return building, nil
 }
+
+func (r *roomResolver) Schedule(ctx context.Context, obj *models.Room, term int) ([]*models.RoomBooking, error) {
+	courses, err := r.loaders(ctx).CoursesByRoom(ctx, term, obj.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	var meetings []*schedule.Meeting
+	for _, course := range courses {
+		meetings = append(meetings, schedule.Meetings(course)...)
+	}
+	schedule.SortMeetings(meetings)
+
+	bookings := make([]*models.RoomBooking, len(meetings))
+	for i, meeting := range meetings {
+		bookings[i] = &models.RoomBooking{
+			Course:    meeting.Course,
+			Day:       schedule.FormatDay(meeting.Day),
+			StartTime: models.Time{Minutes: meeting.Start, Valid: true},
+			EndTime:   models.Time{Minutes: meeting.End, Valid: true},
+		}
+	}
+
+	return bookings, nil
+}
@@ -0,0 +1,150 @@
+package schedule
+
+import (
+	"fmt"
+	"github.com/andrewmthomas87/northwestern/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+var dayAbbreviations = []struct {
+	abbreviation string
+	day          time.Weekday
+}{
+	{"Mo", time.Monday},
+	{"Tu", time.Tuesday},
+	{"We", time.Wednesday},
+	{"Th", time.Thursday},
+	{"Fr", time.Friday},
+	{"Sa", time.Saturday},
+	{"Su", time.Sunday},
+}
+
+// Meeting is a single weekly occurrence of a course, with times in minutes
+// after midnight.
+type Meeting struct {
+	Course *models.Course
+	Day    time.Weekday
+	Start  int
+	End    int
+}
+
+func (m *Meeting) Overlaps(day time.Weekday, start, end int) bool {
+	return m.Day == day && m.Start < end && start < m.End
+}
+
+// ParseDays reads meeting days in the upstream format ("MoWeFr", "TuTh").
+func ParseDays(s string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for len(s) > 0 {
+		day, err := ParseDay(s[:min(2, len(s))])
+		if err != nil {
+			return nil, fmt.Errorf("invalid meeting days: %s", s)
+		}
+		days = append(days, day)
+		s = s[2:]
+	}
+
+	return days, nil
+}
+
+func ParseDay(s string) (time.Weekday, error) {
+	for _, abbreviation := range dayAbbreviations {
+		if strings.EqualFold(s, abbreviation.abbreviation) {
+			return abbreviation.day, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid day: %s", s)
+}
+
+func FormatDay(day time.Weekday) string {
+	for _, abbreviation := range dayAbbreviations {
+		if abbreviation.day == day {
+			return abbreviation.abbreviation
+		}
+	}
+
+	return ""
+}
+
+func FormatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// Meetings expands a course into its weekly meetings. Courses without usable
+// days or times (e.g. "TBA") have none.
+func Meetings(course *models.Course) []*Meeting {
+	days, err := ParseDays(course.MeetingDays)
+	if err != nil {
+		return nil
+	}
+	if !course.StartTime.Valid || !course.EndTime.Valid {
+		return nil
+	}
+	start, end := course.StartTime.Minutes, course.EndTime.Minutes
+	if end <= start {
+		return nil
+	}
+
+	meetings := make([]*Meeting, len(days))
+	for i, day := range days {
+		meetings[i] = &Meeting{Course: course, Day: day, Start: start, End: end}
+	}
+
+	return meetings
+}
+
+// SortMeetings orders meetings through the week, Monday first.
+func SortMeetings(meetings []*Meeting) {
+	sort.Slice(meetings, func(i, j int) bool {
+		if meetings[i].Day != meetings[j].Day {
+			return weekIndex(meetings[i].Day) < weekIndex(meetings[j].Day)
+		}
+		return meetings[i].Start < meetings[j].Start
+	})
+}
+
+func weekIndex(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+// OccupiedRooms returns the rooms that have a course meeting at any point
+// between start and end on day.
+func OccupiedRooms(courses []*models.Course, day time.Weekday, start, end int) map[int]bool {
+	occupied := make(map[int]bool)
+	for _, course := range courses {
+		if course.Room == 0 || occupied[course.Room] {
+			continue
+		}
+		for _, meeting := range Meetings(course) {
+			if meeting.Overlaps(day, start, end) {
+				occupied[course.Room] = true
+				break
+			}
+		}
+	}
+
+	return occupied
+}
+
+// HourlyActivity counts, for each hour of the day, how many meetings over the
+// week take place during at least part of that hour.
+func HourlyActivity(meetings []*Meeting) map[int]int {
+	activity := make(map[int]int)
+	for _, meeting := range meetings {
+		for hour := meeting.Start / 60; hour*60 < meeting.End; hour++ {
+			activity[hour]++
+		}
+	}
+
+	return activity
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,93 @@
+package schedule
+
+import (
+	"github.com/andrewmthomas87/northwestern/geo"
+	"github.com/andrewmthomas87/northwestern/models"
+)
+
+// Walking pace in meters per minute, and how much longer the walk between two
+// buildings is than the straight line between them.
+const (
+	WalkingSpeed = 80
+	RouteFactor  = 1.3
+)
+
+// Transition is the walk between two back-to-back meetings on the same day.
+type Transition struct {
+	From             *Meeting
+	To               *Meeting
+	Distance         float64
+	WalkingMinutes   float64
+	AvailableMinutes int
+	Tight            bool
+}
+
+// Conflict is a pair of meetings that can't both be attended. Soft conflicts
+// don't overlap but can't be walked between in time.
+type Conflict struct {
+	First  *Meeting
+	Second *Meeting
+	Soft   bool
+}
+
+// Transitions finds consecutive meetings on each day and estimates the walk
+// between them. buildings maps room ids to the building the room is in;
+// meetings in rooms without a known building are skipped.
+func Transitions(meetings []*Meeting, buildings map[int]*models.Building) []*Transition {
+	sorted := append([]*Meeting{}, meetings...)
+	SortMeetings(sorted)
+
+	var transitions []*Transition
+	for i := 0; i+1 < len(sorted); i++ {
+		from, to := sorted[i], sorted[i+1]
+		if from.Day != to.Day || to.Start < from.End {
+			continue
+		}
+
+		fromBuilding, ok := buildings[from.Course.Room]
+		if !ok {
+			continue
+		}
+		toBuilding, ok := buildings[to.Course.Room]
+		if !ok {
+			continue
+		}
+
+		distance := geo.Distance(fromBuilding.Lat, fromBuilding.Lon, toBuilding.Lat, toBuilding.Lon) * RouteFactor
+		walkingMinutes := distance / WalkingSpeed
+		availableMinutes := to.Start - from.End
+		transitions = append(transitions, &Transition{
+			From:             from,
+			To:               to,
+			Distance:         distance,
+			WalkingMinutes:   walkingMinutes,
+			AvailableMinutes: availableMinutes,
+			Tight:            walkingMinutes > float64(availableMinutes),
+		})
+	}
+
+	return transitions
+}
+
+// Conflicts reports every pair of overlapping meetings, plus the tight
+// transitions as soft conflicts when softConflicts is set.
+func Conflicts(meetings []*Meeting, transitions []*Transition, softConflicts bool) []*Conflict {
+	var conflicts []*Conflict
+	for i, first := range meetings {
+		for _, second := range meetings[i+1:] {
+			if first.Course.Id != second.Course.Id && first.Overlaps(second.Day, second.Start, second.End) {
+				conflicts = append(conflicts, &Conflict{First: first, Second: second})
+			}
+		}
+	}
+
+	if softConflicts {
+		for _, transition := range transitions {
+			if transition.Tight {
+				conflicts = append(conflicts, &Conflict{First: transition.From, Second: transition.To, Soft: true})
+			}
+		}
+	}
+
+	return conflicts
+}
@@ -6,8 +6,8 @@ CREATE TABLE terms
 (
     id         INT,
     name       VARCHAR(100),
-    start_date VARCHAR(30),
-    end_date   VARCHAR(30),
+    start_date DATE NOT NULL,
+    end_date   DATE NOT NULL,
     PRIMARY KEY (id)
 );
 
@@ -77,10 +77,10 @@ CREATE TABLE courses
     section      VARCHAR(30),
     room         INT,
     meeting_days VARCHAR(30),
-    start_time   VARCHAR(30),
-    end_time     VARCHAR(30),
-    start_date   VARCHAR(30),
-    end_date     VARCHAR(30),
+    start_time   TIME NULL,
+    end_time     TIME NULL,
+    start_date   DATE NULL,
+    end_date     DATE NULL,
     seats        INT,
     overview     VARCHAR(5000),
     topic        VARCHAR(2500),
@@ -105,9 +105,223 @@ CREATE TABLE course_components
     course       INT,
     component    VARCHAR(30),
     meeting_days VARCHAR(30),
-    start_time   VARCHAR(30),
-    end_time     VARCHAR(30),
+    start_time   TIME NULL,
+    end_time     TIME NULL,
     section      VARCHAR(30),
     room         VARCHAR(250),
     UNIQUE (course, component, section)
 );
+
+CREATE TABLE attributes
+(
+    symbol   VARCHAR(100),
+    name     VARCHAR(250),
+    category VARCHAR(30),
+    PRIMARY KEY (symbol)
+);
+
+CREATE TABLE course_attributes
+(
+    course    INT,
+    attribute VARCHAR(100),
+    UNIQUE (course, attribute)
+);
+
+CREATE TABLE course_requirements
+(
+    course      INT,
+    subject     VARCHAR(30),
+    catalog_num VARCHAR(30),
+    UNIQUE (course, subject, catalog_num)
+);
+
+CREATE TABLE invite_codes
+(
+    code       VARCHAR(100),
+    max_uses   INT,
+    uses       INT      DEFAULT 0,
+    expires_at DATETIME NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (code)
+);
+
+CREATE TABLE invited_users
+(
+    email VARCHAR(250),
+    code  VARCHAR(100),
+    PRIMARY KEY (email)
+);
+
+CREATE TABLE denied_users
+(
+    email      VARCHAR(250),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (email)
+);
+
+CREATE TABLE sessions
+(
+    jti                   VARCHAR(64),
+    email                 VARCHAR(250),
+    refresh_hash          CHAR(64),
+    previous_refresh_hash CHAR(64),
+    created_at            DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at            DATETIME,
+    revoked               BOOLEAN  DEFAULT FALSE,
+    PRIMARY KEY (jti),
+    UNIQUE (refresh_hash),
+    INDEX (previous_refresh_hash),
+    INDEX (email)
+);
+
+CREATE TABLE users
+(
+    email           VARCHAR(250),
+    created_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+    last_sign_in_at DATETIME NULL,
+    PRIMARY KEY (email)
+);
+
+CREATE TABLE user_roles
+(
+    email VARCHAR(250),
+    role  VARCHAR(30),
+    UNIQUE (email, role)
+);
+
+CREATE TABLE scrape_requests
+(
+    id           INT AUTO_INCREMENT,
+    term         VARCHAR(250) NULL,
+    requested_by VARCHAR(250),
+    status       VARCHAR(30) DEFAULT 'PENDING',
+    error        TEXT NULL,
+    created_at   DATETIME    DEFAULT CURRENT_TIMESTAMP,
+    started_at   DATETIME NULL,
+    finished_at  DATETIME NULL,
+    PRIMARY KEY (id),
+    INDEX (status)
+);
+
+CREATE TABLE data_corrections
+(
+    id         INT AUTO_INCREMENT,
+    entity     VARCHAR(30),
+    record_id  INT,
+    field      VARCHAR(100),
+    value      TEXT,
+    created_by VARCHAR(250),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    UNIQUE (entity, record_id, field)
+);
+
+CREATE TABLE api_keys
+(
+    id           INT AUTO_INCREMENT,
+    email        VARCHAR(250),
+    name         VARCHAR(250),
+    prefix       VARCHAR(30),
+    key_hash     CHAR(64),
+    scope        VARCHAR(30),
+    created_at   DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at   DATETIME NULL,
+    last_used_at DATETIME NULL,
+    revoked      BOOLEAN  DEFAULT FALSE,
+    PRIMARY KEY (id),
+    UNIQUE (key_hash),
+    INDEX (email)
+);
+
+CREATE TABLE persisted_queries
+(
+    hash         CHAR(64),
+    query        MEDIUMTEXT,
+    registered   BOOLEAN  DEFAULT FALSE,
+    created_at   DATETIME DEFAULT CURRENT_TIMESTAMP,
+    last_used_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (hash),
+    INDEX (registered, last_used_at)
+);
+
+CREATE TABLE course_changes
+(
+    id             INT AUTO_INCREMENT,
+    course         INT,
+    term           INT,
+    subject        VARCHAR(30),
+    kind           VARCHAR(30),
+    fields         VARCHAR(1000),
+    previous_seats INT,
+    seats          INT,
+    created_at     DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    INDEX (created_at)
+);
+
+CREATE TABLE watches
+(
+    email      VARCHAR(250),
+    course     INT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (email, course),
+    INDEX (course)
+);
+
+CREATE TABLE notification_preferences
+(
+    email          VARCHAR(250),
+    channel        VARCHAR(30) DEFAULT 'EMAIL',
+    frequency      VARCHAR(30) DEFAULT 'IMMEDIATE',
+    webhook_url    VARCHAR(1000) NULL,
+    last_digest_at DATETIME NULL,
+    PRIMARY KEY (email)
+);
+
+CREATE TABLE notifications
+(
+    id             INT AUTO_INCREMENT,
+    email          VARCHAR(250),
+    course         INT,
+    kind           VARCHAR(30) DEFAULT 'UPDATED',
+    snapshot       TEXT     NULL,
+    fields         VARCHAR(1000),
+    previous_seats INT,
+    seats          INT,
+    attempts       INT      DEFAULT 0,
+    created_at     DATETIME DEFAULT CURRENT_TIMESTAMP,
+    sent_at        DATETIME NULL,
+    PRIMARY KEY (id),
+    INDEX (email, sent_at)
+);
+
+CREATE TABLE webhooks
+(
+    id         INT AUTO_INCREMENT,
+    url        VARCHAR(1000),
+    secret     VARCHAR(100),
+    events     VARCHAR(1000),
+    subjects   VARCHAR(1000) NULL,
+    created_by VARCHAR(250),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    deleted    BOOLEAN  DEFAULT FALSE,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE webhook_deliveries
+(
+    id               INT AUTO_INCREMENT,
+    webhook          INT,
+    event            VARCHAR(30),
+    payload          MEDIUMTEXT,
+    status           VARCHAR(30) DEFAULT 'PENDING',
+    attempts         INT         DEFAULT 0,
+    last_status_code INT NULL,
+    last_error       TEXT NULL,
+    created_at       DATETIME    DEFAULT CURRENT_TIMESTAMP,
+    next_attempt_at  DATETIME NULL,
+    delivered_at     DATETIME NULL,
+    PRIMARY KEY (id),
+    INDEX (status, next_attempt_at),
+    INDEX (webhook)
+);
@@ -4,65 +4,98 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"github.com/andrewmthomas87/northwestern/changes"
 	"github.com/andrewmthomas87/northwestern/course_data_api"
 	"github.com/andrewmthomas87/northwestern/database"
 	"github.com/andrewmthomas87/northwestern/models"
+	"github.com/andrewmthomas87/northwestern/prerequisites"
+	"github.com/andrewmthomas87/northwestern/webhooks"
 	"github.com/spf13/viper"
 	"log"
+	"time"
 )
 
-func terms(ctx context.Context, db *database.Database, apiClient *course_data_api.Client, term *models.Term) {
+// flagInvalidValues reports dates and times upstream sent that couldn't be
+// parsed. Course values can be fixed with a data correction until upstream is.
+func flagInvalidValues(invalid []*course_data_api.InvalidValue) {
+	for _, value := range invalid {
+		fmt.Printf("Flagged: %s\n", value)
+	}
+}
+
+func terms(ctx context.Context, db *database.Database, apiClient *course_data_api.Client, term *models.Term) error {
 	if term != nil {
 		fmt.Println("Skipping terms")
 
-		return
+		return nil
 	}
 
 	fmt.Println("Fetching terms")
 
-	terms, err := apiClient.Terms()
+	terms, invalid, err := apiClient.Terms()
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	flagInvalidValues(invalid)
+
+	previous, err := db.SelectAllTerms(ctx)
+	if err != nil {
+		return err
 	}
 
 	fmt.Println("Storing terms")
 
 	err = db.InsertTerms(ctx, terms)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+
+	known := make(map[int]bool, len(previous))
+	for _, term := range previous {
+		known[term.Id] = true
 	}
+	var published []*models.Term
+	for _, term := range terms {
+		if !known[term.Id] {
+			published = append(published, term)
+		}
+	}
+
+	return webhooks.Enqueue(ctx, db, webhooks.TermEvents(published))
 }
 
-func schools(ctx context.Context, db *database.Database, apiClient *course_data_api.Client, term *models.Term) {
+func schools(ctx context.Context, db *database.Database, apiClient *course_data_api.Client, term *models.Term) error {
 	if term != nil {
 		fmt.Println("Skipping schools")
 
-		return
+		return nil
 	}
 
 	fmt.Println("Fetching schools")
 
 	schools, err := apiClient.Schools()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	fmt.Println("Storing schools")
 
 	err = db.InsertSchools(ctx, schools)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+
+	return nil
 }
 
-func subjects(ctx context.Context, db *database.Database, apiClient *course_data_api.Client, term *models.Term) {
+func subjects(ctx context.Context, db *database.Database, apiClient *course_data_api.Client, term *models.Term) error {
 	var terms []*models.Term
 	if term != nil {
 		fmt.Printf("Deleting subject availabilities for term %s\n", term.Name)
 
 		err := db.DeleteSubjectAvailabilitiesByTerm(ctx, term.Id)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 
 		fmt.Printf("Fetching subjects for term %s\n", term.Name)
@@ -74,13 +107,13 @@ func subjects(ctx context.Context, db *database.Database, apiClient *course_data
 		var err error
 		terms, err = db.SelectAllTerms(ctx)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 	}
 
 	schools, err := db.SelectAllSchools(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	var subjects []*models.Subject
@@ -89,7 +122,7 @@ func subjects(ctx context.Context, db *database.Database, apiClient *course_data
 		for _, school := range schools {
 			filteredSubjects, err := apiClient.Subjects(term.Id, school.Symbol)
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
 
 			subjects = append(subjects, filteredSubjects...)
@@ -107,26 +140,28 @@ func subjects(ctx context.Context, db *database.Database, apiClient *course_data
 
 	err = db.InsertSubjects(ctx, subjects)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	err = db.InsertSubjectAvailabilities(ctx, subjectAvailabilities)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+
+	return nil
 }
 
-func instructors(ctx context.Context, db *database.Database, apiClient *course_data_api.Client, term *models.Term) {
+func instructors(ctx context.Context, db *database.Database, apiClient *course_data_api.Client, term *models.Term) error {
 	if term != nil {
 		fmt.Println("Skipping instructors")
 
-		return
+		return nil
 	}
 
 	fmt.Println("Fetching instructors")
 
 	subjects, err := db.SelectAllSubjects(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	var instructors []*models.Instructor
@@ -134,7 +169,7 @@ func instructors(ctx context.Context, db *database.Database, apiClient *course_d
 	for _, subject := range subjects {
 		filteredInstructors, err := apiClient.Instructors(subject.Symbol)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 
 		instructors = append(instructors, filteredInstructors...)
@@ -150,55 +185,59 @@ func instructors(ctx context.Context, db *database.Database, apiClient *course_d
 
 	err = db.InsertInstructors(ctx, instructors)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	err = db.InsertInstructorSubjects(ctx, instructorSubjects)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+
+	return nil
 }
 
-func buildings(ctx context.Context, db *database.Database, apiClient *course_data_api.Client, term *models.Term) {
+func buildings(ctx context.Context, db *database.Database, apiClient *course_data_api.Client, term *models.Term) error {
 	if term != nil {
 		fmt.Println("Skipping buildings")
 
-		return
+		return nil
 	}
 
 	fmt.Println("Fetching buildings")
 
 	buildings, err := apiClient.Buildings()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	fmt.Println("Storing buildings")
 
 	err = db.InsertBuildings(ctx, buildings)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+
+	return nil
 }
 
-func rooms(ctx context.Context, db *database.Database, apiClient *course_data_api.Client, term *models.Term) {
+func rooms(ctx context.Context, db *database.Database, apiClient *course_data_api.Client, term *models.Term) error {
 	if term != nil {
 		fmt.Println("Skipping rooms")
 
-		return
+		return nil
 	}
 
 	fmt.Println("Fetching rooms")
 
 	buildings, err := db.SelectAllBuildings(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	var rooms []*models.Room
 	for _, building := range buildings {
 		filteredRooms, err := apiClient.Rooms(building.Id)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 
 		rooms = append(rooms, filteredRooms...)
@@ -208,21 +247,26 @@ func rooms(ctx context.Context, db *database.Database, apiClient *course_data_ap
 
 	err = db.InsertRooms(ctx, rooms)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+
+	return nil
 }
 
-func courses(ctx context.Context, db *database.Database, apiClient *course_data_api.Client, term *models.Term) {
+// courses stores a term's courses and returns the ones that are no longer
+// listed, which is how cancelled sections show up. They're left for
+// recordCourseChanges to delete once their watchers have been notified.
+func courses(ctx context.Context, db *database.Database, apiClient *course_data_api.Client, term *models.Term, previous []*models.Course) ([]int, error) {
 	fmt.Printf("Fetching courses for term %s\n", term.Name)
 
 	subjects, err := db.SelectSubjectsByTerm(ctx, term.Id)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	instructors, err := db.SelectAllInstructors(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	instructorsMap := make(map[string]int, len(instructors))
@@ -233,10 +277,15 @@ func courses(ctx context.Context, db *database.Database, apiClient *course_data_
 	var courses []*models.Course
 	var courseDescriptions []*models.CourseDescription
 	var courseComponents []*models.CourseComponent
+	listedSubjects := make(map[string]bool, len(subjects))
 	for _, subject := range subjects {
-		filteredCourses, filteredCourseDescriptions, filteredCourseComponents, err := apiClient.Courses(term.Id, subject.Symbol, instructorsMap)
+		filteredCourses, filteredCourseDescriptions, filteredCourseComponents, invalid, err := apiClient.Courses(term.Id, subject.Symbol, instructorsMap)
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
+		}
+		flagInvalidValues(invalid)
+		if len(filteredCourses) > 0 {
+			listedSubjects[subject.Symbol] = true
 		}
 
 		courses = append(courses, filteredCourses...)
@@ -248,17 +297,229 @@ func courses(ctx context.Context, db *database.Database, apiClient *course_data_
 
 	err = db.InsertCourses(ctx, courses)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+
+	// An empty listing for a subject is far more likely to be an API problem
+	// than every section in it being cancelled, so those subjects keep their
+	// courses.
+	listed := make(map[int]bool, len(courses))
+	for _, course := range courses {
+		listed[course.Id] = true
+	}
+	var removed []int
+	for _, course := range previous {
+		if listedSubjects[course.Subject] && !listed[course.Id] {
+			removed = append(removed, course.Id)
+		}
 	}
 
 	err = db.InsertCourseDescriptions(ctx, courseDescriptions)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	err = db.InsertCourseComponents(ctx, courseComponents)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+
+	fmt.Println("Storing course attributes")
+
+	var attributes []*models.Attribute
+	var courseAttributes []*models.CourseAttribute
+	for _, course := range courses {
+		for _, attribute := range course_data_api.ParseAttributes(course.Attributes) {
+			attributes = append(attributes, attribute)
+			courseAttributes = append(courseAttributes, &models.CourseAttribute{
+				Course:    course.Id,
+				Attribute: attribute.Symbol,
+			})
+		}
+	}
+
+	err = db.DeleteCourseAttributesByTerm(ctx, term.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.InsertAttributes(ctx, attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.InsertCourseAttributes(ctx, courseAttributes)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Println("Storing course requirements")
+
+	allSubjects, err := db.SelectAllSubjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	subjectsMap := make(map[string]bool, len(allSubjects))
+	for _, subject := range allSubjects {
+		subjectsMap[subject.Symbol] = true
+	}
+
+	var courseRequirements []*models.CourseRequirement
+	for _, course := range courses {
+		courseRequirements = append(courseRequirements, prerequisites.Parse(course, subjectsMap)...)
+	}
+
+	err = db.DeleteCourseRequirementsByTerm(ctx, term.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.InsertCourseRequirements(ctx, courseRequirements)
+	if err != nil {
+		return nil, err
+	}
+
+	return removed, nil
+}
+
+func applyDataCorrections(ctx context.Context, db *database.Database) error {
+	fmt.Println("Applying data corrections")
+
+	return db.ApplyDataCorrections(ctx)
+}
+
+// courseChangeRetentionDays is how long recorded changes are kept for the
+// server to pick up.
+const courseChangeRetentionDays = 7
+
+// recordCourseChanges compares a term's courses with what they were before the
+// scrape, after corrections so corrected fields don't look changed every time,
+// and queues notifications for anyone watching the courses that changed and
+// deliveries for webhooks interested in them.
+func recordCourseChanges(ctx context.Context, db *database.Database, term *models.Term, previous []*models.Course, removed []int) error {
+	fmt.Println("Recording course changes")
+
+	stored, err := db.SelectCourses(ctx, term.Id, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	isRemoved := make(map[int]bool, len(removed))
+	for _, id := range removed {
+		isRemoved[id] = true
+	}
+	var current []*models.Course
+	for _, course := range stored {
+		if !isRemoved[course.Id] {
+			current = append(current, course)
+		}
+	}
+	var removedCourses []*models.Course
+	for _, course := range previous {
+		if isRemoved[course.Id] {
+			removedCourses = append(removedCourses, course)
+		}
+	}
+
+	last, err := db.SelectLastCourseChangeID(ctx)
+	if err != nil {
+		return err
+	}
+
+	courseChanges := changes.Detect(previous, current)
+	if err := db.InsertCourseChanges(ctx, courseChanges); err != nil {
+		return err
+	}
+
+	if err := webhooks.Enqueue(ctx, db, webhooks.CourseEvents(courseChanges)); err != nil {
+		return err
+	}
+
+	if err := db.InsertWatchNotifications(ctx, last, removedCourses); err != nil {
+		return err
+	}
+
+	if err := db.DeleteCourses(ctx, removed); err != nil {
+		return err
+	}
+
+	return db.DeleteCourseChangesBefore(ctx, courseChangeRetentionDays)
+}
+
+// scrape fetches everything, or only what changes per term when termName is
+// set. courseTermName fetches just the courses for that term.
+func scrape(ctx context.Context, db *database.Database, apiClient *course_data_api.Client, termName string, courseTermName string) error {
+	if len(courseTermName) > 0 {
+		term, err := db.SelectTermByName(ctx, courseTermName)
+		if err != nil {
+			return err
+		}
+
+		previous, err := db.SelectCourses(ctx, term.Id, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		removed, err := courses(ctx, db, apiClient, term, previous)
+		if err != nil {
+			return err
+		}
+
+		if err := applyDataCorrections(ctx, db); err != nil {
+			return err
+		}
+
+		return recordCourseChanges(ctx, db, term, previous, removed)
+	}
+
+	var term *models.Term
+	if len(termName) > 0 {
+		var err error
+		term, err = db.SelectTermByName(ctx, termName)
+		if err != nil {
+			return err
+		}
+	}
+
+	steps := []func(context.Context, *database.Database, *course_data_api.Client, *models.Term) error{terms, schools, subjects, instructors, buildings, rooms}
+	for _, step := range steps {
+		if err := step(ctx, db, apiClient, term); err != nil {
+			return err
+		}
+	}
+
+	return applyDataCorrections(ctx, db)
+}
+
+// watch runs scrape requests queued by admins, oldest first. A request for a
+// term fetches that term's courses; one without a term runs a full scrape.
+func watch(ctx context.Context, db *database.Database, apiClient *course_data_api.Client, interval time.Duration) {
+	for {
+		request, err := db.ClaimScrapeRequest(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if request == nil {
+			time.Sleep(interval)
+			continue
+		}
+
+		fmt.Printf("Running scrape request %d from %s\n", request.ID, request.RequestedBy)
+
+		var courseTermName string
+		if request.Term != nil {
+			courseTermName = *request.Term
+		}
+
+		scrapeErr := scrape(ctx, db, apiClient, "", courseTermName)
+		if scrapeErr != nil {
+			log.Println(scrapeErr)
+		}
+
+		if err := db.FinishScrapeRequest(ctx, request.ID, scrapeErr); err != nil {
+			log.Fatal(err)
+		}
 	}
 }
 
@@ -267,8 +528,11 @@ func main() {
 
 	termName := flag.String("term", "", "fetch data for a specific term")
 	courseTermName := flag.String("courses", "", "fetch course data for a specific term")
+	watchRequests := flag.Bool("watch", false, "keep running, serving scrape requests made through the API")
 	flag.Parse()
 
+	viper.SetDefault("scraper.pollInterval", time.Minute)
+
 	viper.SetConfigName("config")
 	viper.AddConfigPath(".")
 	if err := viper.ReadInConfig(); err != nil {
@@ -283,26 +547,12 @@ func main() {
 
 	apiClient := course_data_api.NewClient(viper.GetString("courseDataAPI.baseUrl"), viper.GetString("courseDataAPI.apiKey"), viper.GetString("courseDataAPI.apiKeyParameter"))
 
-	var term *models.Term
-	if len(*courseTermName) > 0 {
-		term, err = db.SelectTermByName(ctx, *courseTermName)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		courses(ctx, db, apiClient, term)
+	if *watchRequests {
+		watch(ctx, db, apiClient, viper.GetDuration("scraper.pollInterval"))
 		return
-	} else if len(*termName) > 0 {
-		term, err = db.SelectTermByName(ctx, *termName)
-		if err != nil {
-			log.Fatal(err)
-		}
 	}
 
-	terms(ctx, db, apiClient, term)
-	schools(ctx, db, apiClient, term)
-	subjects(ctx, db, apiClient, term)
-	instructors(ctx, db, apiClient, term)
-	buildings(ctx, db, apiClient, term)
-	rooms(ctx, db, apiClient, term)
+	if err := scrape(ctx, db, apiClient, *termName, *courseTermName); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const (
+	APIKeyPrefix = "nwk_"
+
+	APIKeyScopeRead      = "READ"
+	APIKeyScopeReadWrite = "READ_WRITE"
+
+	apiKeyDisplayLength = 12
+)
+
+var InvalidAPIKeyError = errors.New("invalid API key")
+
+// APIKeyStore looks up API keys by the hash of the key, which is all that is
+// stored. AuthenticateAPIKey only finds keys that are neither revoked nor
+// expired, and records that the key was used.
+type APIKeyStore interface {
+	AuthenticateAPIKey(ctx context.Context, keyHash string) (string, string, bool, error)
+}
+
+// NewAPIKey returns a new key, the hash to store for it, and a prefix short
+// enough to show users so they can tell their keys apart.
+func NewAPIKey() (string, string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+
+	key := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, HashAPIKey(key), key[:apiKeyDisplayLength], nil
+}
+
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+type APIKeys struct {
+	store APIKeyStore
+}
+
+func NewAPIKeys(store APIKeyStore) *APIKeys {
+	return &APIKeys{store: store}
+}
+
+// Authenticate returns the email of the key's owner and whether the key is
+// limited to reads.
+func (k *APIKeys) Authenticate(ctx context.Context, key string) (string, bool, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return "", false, InvalidAPIKeyError
+	}
+
+	email, scope, ok, err := k.store.AuthenticateAPIKey(ctx, HashAPIKey(key))
+	if err != nil {
+		return "", false, err
+	}
+	if !ok {
+		return "", false, InvalidAPIKeyError
+	}
+
+	return email, scope != APIKeyScopeReadWrite, nil
+}
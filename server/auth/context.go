@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"context"
+)
+
+type contextKey int
+
+const (
+	emailContextKey contextKey = iota
+	apiKeyContextKey
+)
+
+// WithEmail stores the authenticated user's email in ctx so code below the
+// HTTP layer, like GraphQL resolvers, can find out who is asking.
+func WithEmail(ctx context.Context, email string) context.Context {
+	return context.WithValue(ctx, emailContextKey, email)
+}
+
+func EmailFromContext(ctx context.Context) (string, bool) {
+	email, ok := ctx.Value(emailContextKey).(string)
+	return email, ok && len(email) > 0
+}
+
+// WithAPIKey marks ctx as authenticated by an API key rather than a session.
+func WithAPIKey(ctx context.Context, readOnly bool) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey, readOnly)
+}
+
+// APIKeyFromContext reports whether the request was authenticated by an API
+// key and, if so, whether that key is read-only.
+func APIKeyFromContext(ctx context.Context) (bool, bool) {
+	readOnly, ok := ctx.Value(apiKeyContextKey).(bool)
+	return readOnly, ok
+}
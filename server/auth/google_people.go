@@ -76,6 +76,9 @@ func (gp *GooglePeople) Me(accessCode string) (string, error) {
 
 	for _, emailAddress := range data.EmailAddresses {
 		if emailAddress.Metadata.Primary {
+			if !emailAddress.Metadata.Verified {
+				return "", emailNotVerifiedError
+			}
 			return emailAddress.Value, nil
 		}
 	}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"time"
+)
+
+const GoogleCertsURL = "https://www.googleapis.com/oauth2/v3/certs"
+
+var GoogleIssuers = []string{"accounts.google.com", "https://accounts.google.com"}
+
+var (
+	emailNotVerifiedError = errors.New("email address not verified")
+	invalidIdTokenError   = errors.New("invalid id token")
+)
+
+type idTokenClaims struct {
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"`
+	jwt.StandardClaims
+}
+
+// emailVerified accepts both the boolean and the string form of the claim;
+// Google has issued both.
+func (c *idTokenClaims) emailVerified() bool {
+	switch verified := c.EmailVerified.(type) {
+	case bool:
+		return verified
+	case string:
+		return verified == "true"
+	default:
+		return false
+	}
+}
+
+// IDTokenVerifier checks OpenID Connect ID tokens locally against the issuer's
+// published keys, instead of calling back to the issuer on every sign-in.
+type IDTokenVerifier struct {
+	keys      KeySource
+	audiences []string
+	issuers   []string
+	leeway    time.Duration
+}
+
+func NewIDTokenVerifier(keys KeySource, audiences []string, issuers []string) *IDTokenVerifier {
+	return &IDTokenVerifier{keys: keys, audiences: audiences, issuers: issuers, leeway: time.Minute}
+}
+
+func NewGoogleIDTokenVerifier(clientIds []string) *IDTokenVerifier {
+	return NewIDTokenVerifier(NewRemoteKeySet(GoogleCertsURL), clientIds, GoogleIssuers)
+}
+
+func (v *IDTokenVerifier) Verify(ctx context.Context, tokenString string) (string, error) {
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.ParseWithClaims(tokenString, &idTokenClaims{}, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		default:
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		return v.keys.Key(ctx, kid)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	claims, ok := token.Claims.(*idTokenClaims)
+	if !ok || !token.Valid {
+		return "", invalidIdTokenError
+	}
+
+	now := time.Now()
+	if claims.ExpiresAt == 0 || now.Add(-v.leeway).Unix() > claims.ExpiresAt {
+		return "", errors.New("id token expired")
+	}
+	if claims.IssuedAt > now.Add(v.leeway).Unix() || claims.NotBefore > now.Add(v.leeway).Unix() {
+		return "", errors.New("id token not valid yet")
+	}
+	if !contains(v.issuers, claims.Issuer) {
+		return "", fmt.Errorf("unexpected issuer: %s", claims.Issuer)
+	}
+	if !contains(v.audiences, claims.Audience) {
+		return "", fmt.Errorf("unexpected audience: %s", claims.Audience)
+	}
+	if len(claims.Email) == 0 {
+		return "", errors.New("no email address")
+	}
+	if !claims.emailVerified() {
+		return "", emailNotVerifiedError
+	}
+
+	return claims.Email, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
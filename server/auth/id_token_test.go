@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"github.com/dgrijalva/jwt-go"
+	"testing"
+	"time"
+)
+
+func TestIDTokenVerifierVerify(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier := NewIDTokenVerifier(NewStaticKeySet(map[string]interface{}{"google": &key.PublicKey}), []string{"client"}, GoogleIssuers)
+
+	now := time.Now()
+	valid := func() *idTokenClaims {
+		return &idTokenClaims{
+			Email:         "someone@u.northwestern.edu",
+			EmailVerified: true,
+			StandardClaims: jwt.StandardClaims{
+				Issuer:    "https://accounts.google.com",
+				Audience:  "client",
+				Subject:   "1234",
+				IssuedAt:  now.Unix(),
+				ExpiresAt: now.Add(time.Hour).Unix(),
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		claims  func() *idTokenClaims
+		signKey *ecdsa.PrivateKey
+		ok      bool
+	}{
+		{name: "valid", claims: valid, signKey: key, ok: true},
+		{name: "email_verified as a string", claims: func() *idTokenClaims {
+			claims := valid()
+			claims.EmailVerified = "true"
+			return claims
+		}, signKey: key, ok: true},
+		{name: "bad signature", claims: valid, signKey: otherKey},
+		{name: "wrong audience", claims: func() *idTokenClaims {
+			claims := valid()
+			claims.Audience = "someone-else"
+			return claims
+		}, signKey: key},
+		{name: "wrong issuer", claims: func() *idTokenClaims {
+			claims := valid()
+			claims.Issuer = "https://accounts.example.com"
+			return claims
+		}, signKey: key},
+		{name: "expired", claims: func() *idTokenClaims {
+			claims := valid()
+			claims.IssuedAt = now.Add(-2 * time.Hour).Unix()
+			claims.ExpiresAt = now.Add(-time.Hour).Unix()
+			return claims
+		}, signKey: key},
+		{name: "email not verified", claims: func() *idTokenClaims {
+			claims := valid()
+			claims.EmailVerified = false
+			return claims
+		}, signKey: key},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token := jwt.NewWithClaims(jwt.SigningMethodES256, test.claims())
+			token.Header["kid"] = "google"
+			tokenString, err := token.SignedString(test.signKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			email, err := verifier.Verify(context.Background(), tokenString)
+			if test.ok {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if email != "someone@u.northwestern.edu" {
+					t.Errorf("email = %q, want someone@u.northwestern.edu", email)
+				}
+			} else if err == nil {
+				t.Error("token was accepted")
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var unknownKeyError = errors.New("unknown signing key")
+
+// KeySource looks up the public key a token was signed with by its kid.
+type KeySource interface {
+	Key(ctx context.Context, kid string) (interface{}, error)
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []*JWK `json:"keys"`
+}
+
+func (k *JWK) PublicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve: %s", k.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (s *JWKS) publicKeys() (map[string]interface{}, error) {
+	keys := make(map[string]interface{}, len(s.Keys))
+	for _, jwk := range s.Keys {
+		key, err := jwk.PublicKey()
+		if err != nil {
+			return nil, err
+		}
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+// StaticKeySet serves a fixed set of keys, e.g. for tests or keys distributed
+// out of band.
+type StaticKeySet struct {
+	keys map[string]interface{}
+}
+
+func NewStaticKeySet(keys map[string]interface{}) *StaticKeySet {
+	return &StaticKeySet{keys: keys}
+}
+
+func ParseStaticKeySet(data []byte) (*StaticKeySet, error) {
+	jwks := &JWKS{}
+	if err := json.Unmarshal(data, jwks); err != nil {
+		return nil, err
+	}
+
+	keys, err := jwks.publicKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	return &StaticKeySet{keys: keys}, nil
+}
+
+func (s *StaticKeySet) Key(ctx context.Context, kid string) (interface{}, error) {
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+
+	return nil, unknownKeyError
+}
+
+const (
+	defaultKeySetMaxAge   = time.Hour
+	minKeySetRefreshDelay = time.Minute
+)
+
+var maxAgePattern = regexp.MustCompile(`max-age=(\d+)`)
+
+// RemoteKeySet fetches a JWKS document over HTTP and caches it for as long as
+// its Cache-Control header allows. An unknown kid triggers a refresh, at most
+// once a minute, so rotated keys are picked up early.
+type RemoteKeySet struct {
+	url    string
+	client *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]interface{}
+	expiry      time.Time
+	lastRefresh time.Time
+}
+
+func NewRemoteKeySet(url string) *RemoteKeySet {
+	return &RemoteKeySet{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (s *RemoteKeySet) Key(ctx context.Context, kid string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	key, ok := s.keys[kid]
+	if now.Before(s.expiry) && (ok || now.Sub(s.lastRefresh) < minKeySetRefreshDelay) {
+		if !ok {
+			return nil, unknownKeyError
+		}
+		return key, nil
+	}
+
+	if err := s.refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+
+	return nil, unknownKeyError
+}
+
+func (s *RemoteKeySet) refresh(ctx context.Context) error {
+	req, err := http.NewRequest("GET", s.url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching key set returned %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	jwks := &JWKS{}
+	if err := json.Unmarshal(body, jwks); err != nil {
+		return err
+	}
+
+	keys, err := jwks.publicKeys()
+	if err != nil {
+		return err
+	}
+
+	maxAge := defaultKeySetMaxAge
+	if match := maxAgePattern.FindStringSubmatch(resp.Header.Get("Cache-Control")); match != nil {
+		if seconds, err := strconv.Atoi(match[1]); err == nil {
+			maxAge = time.Duration(seconds) * time.Second
+		}
+	}
+
+	now := time.Now()
+	s.keys = keys
+	s.expiry = now.Add(maxAge)
+	s.lastRefresh = now
+
+	return nil
+}
@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"math/big"
+	"sort"
+	"time"
+)
+
+var noSigningKeyError = errors.New("no key is currently allowed to sign")
+
+// SigningKey is one key in a KeyRing. A key signs new tokens between SignFrom
+// and SignUntil and verifies tokens until VerifyUntil; zero times are
+// unbounded. Publishing a key before its SignFrom lets other services pick it
+// up ahead of the switch, and keeping VerifyUntil past SignUntil lets tokens
+// it already signed run out instead of failing at rotation.
+type SigningKey struct {
+	Kid         string
+	Method      jwt.SigningMethod
+	SignFrom    time.Time
+	SignUntil   time.Time
+	VerifyUntil time.Time
+
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+func NewHMACKey(kid string, alg string, secret []byte) (*SigningKey, error) {
+	method, ok := jwt.GetSigningMethod(alg).(*jwt.SigningMethodHMAC)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an HMAC algorithm", alg)
+	}
+
+	return &SigningKey{Kid: kid, Method: method, signKey: secret, verifyKey: secret}, nil
+}
+
+// ParsePrivateKey reads a PEM encoded private key for an RS*, ES* or EdDSA
+// algorithm.
+func ParsePrivateKey(kid string, alg string, pemData []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data for key %s", kid)
+	}
+
+	var privateKey interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		privateKey, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	method := jwt.GetSigningMethod(alg)
+	var publicKey interface{}
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		if _, ok := method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("key %s is an RSA key, not a %s key", kid, alg)
+		}
+		publicKey = &key.PublicKey
+	case *ecdsa.PrivateKey:
+		ecdsaMethod, ok := method.(*jwt.SigningMethodECDSA)
+		if !ok || ecdsaMethod.CurveBits != key.Curve.Params().BitSize {
+			return nil, fmt.Errorf("key %s is an ECDSA %s key, not a %s key", kid, key.Curve.Params().Name, alg)
+		}
+		publicKey = &key.PublicKey
+	case ed25519.PrivateKey:
+		if method != SigningMethodEd25519 {
+			return nil, fmt.Errorf("key %s is an Ed25519 key, not a %s key", kid, alg)
+		}
+		publicKey = key.Public()
+	default:
+		return nil, fmt.Errorf("unsupported key type for key %s", kid)
+	}
+
+	return &SigningKey{Kid: kid, Method: method, signKey: privateKey, verifyKey: publicKey}, nil
+}
+
+func (k *SigningKey) canSign(now time.Time) bool {
+	return !now.Before(k.SignFrom) && (k.SignUntil.IsZero() || now.Before(k.SignUntil)) && k.canVerify(now)
+}
+
+func (k *SigningKey) canVerify(now time.Time) bool {
+	return k.VerifyUntil.IsZero() || now.Before(k.VerifyUntil)
+}
+
+// JWK returns the public half of the key, or nil for symmetric keys.
+func (k *SigningKey) JWK() *JWK {
+	jwk := &JWK{Kid: k.Kid, Alg: k.Method.Alg(), Use: "sig"}
+	switch key := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = key.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(padLeft(key.X.Bytes(), size))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(padLeft(key.Y.Bytes(), size))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(key)
+	default:
+		return nil
+	}
+
+	return jwk
+}
+
+func padLeft(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+
+	return append(make([]byte, size-len(b)), b...)
+}
+
+// KeyRing holds the keys tokens are signed and verified with. New tokens are
+// signed by the signing-capable key that most recently started signing.
+type KeyRing struct {
+	keys map[string]*SigningKey
+}
+
+func NewKeyRing(keys ...*SigningKey) (*KeyRing, error) {
+	ring := &KeyRing{keys: make(map[string]*SigningKey, len(keys))}
+	for _, key := range keys {
+		if len(key.Kid) == 0 {
+			return nil, errors.New("keys must have a kid")
+		}
+		if _, ok := ring.keys[key.Kid]; ok {
+			return nil, fmt.Errorf("duplicate kid: %s", key.Kid)
+		}
+		ring.keys[key.Kid] = key
+	}
+
+	return ring, nil
+}
+
+func (r *KeyRing) signingKey() (*SigningKey, error) {
+	now := time.Now()
+
+	var signingKey *SigningKey
+	for _, key := range r.keys {
+		if key.canSign(now) && (signingKey == nil || key.SignFrom.After(signingKey.SignFrom) || key.SignFrom.Equal(signingKey.SignFrom) && key.Kid > signingKey.Kid) {
+			signingKey = key
+		}
+	}
+
+	if signingKey == nil {
+		return nil, noSigningKeyError
+	}
+
+	return signingKey, nil
+}
+
+func (r *KeyRing) sign(claims jwt.Claims) (string, error) {
+	key, err := r.signingKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.Kid
+
+	return token.SignedString(key.signKey)
+}
+
+func (r *KeyRing) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := r.keys[kid]
+	if !ok || !key.canVerify(time.Now()) {
+		return nil, unknownKeyError
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.verifyKey, nil
+}
+
+// CanSign reports whether any key is currently able to sign.
+func (r *KeyRing) CanSign() bool {
+	_, err := r.signingKey()
+	return err == nil
+}
+
+// JWKS publishes the public keys that can still verify tokens.
+func (r *KeyRing) JWKS() *JWKS {
+	now := time.Now()
+
+	jwks := &JWKS{Keys: []*JWK{}}
+	for _, key := range r.keys {
+		if !key.canVerify(now) {
+			continue
+		}
+		if jwk := key.JWK(); jwk != nil {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].Kid < jwks.Keys[j].Kid })
+
+	return jwks
+}
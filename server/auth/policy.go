@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var (
+	NotAllowedError    = errors.New("this account is not allowed to use this service")
+	InvalidInviteError = errors.New("invite code is invalid, expired or used up")
+)
+
+// AccessStore keeps the users admins have allowed or denied, along with invite
+// codes and the users who have redeemed one.
+type AccessStore interface {
+	IsInvited(ctx context.Context, email string) (bool, error)
+	IsDenied(ctx context.Context, email string) (bool, error)
+	RedeemInvite(ctx context.Context, code string, email string) (bool, error)
+}
+
+// AccessPolicy decides who may sign in. Denied emails, configured or stored,
+// are always refused; otherwise an email is admitted if it is explicitly
+// allowed, belongs to an allowed domain, or has been allowed by an admin or
+// redeemed an invite code. A policy with no allowed domains, no allowed emails
+// and invite codes turned off admits everyone.
+type AccessPolicy struct {
+	domains     map[string]bool
+	allow       map[string]bool
+	deny        map[string]bool
+	store       AccessStore
+	inviteCodes bool
+}
+
+func NewAccessPolicy(domains, allow, deny []string, store AccessStore, inviteCodes bool) *AccessPolicy {
+	return &AccessPolicy{
+		domains:     lowerSet(domains),
+		allow:       lowerSet(allow),
+		deny:        lowerSet(deny),
+		store:       store,
+		inviteCodes: inviteCodes,
+	}
+}
+
+func lowerSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[strings.ToLower(strings.TrimSpace(value))] = true
+	}
+
+	return set
+}
+
+func (p *AccessPolicy) restricted() bool {
+	return len(p.domains) > 0 || len(p.allow) > 0 || p.inviteCodes
+}
+
+func (p *AccessPolicy) denied(ctx context.Context, email string) (bool, error) {
+	if p.deny[email] {
+		return true, nil
+	}
+
+	return p.store.IsDenied(ctx, email)
+}
+
+func (p *AccessPolicy) Check(ctx context.Context, email string) error {
+	email = strings.ToLower(email)
+
+	denied, err := p.denied(ctx, email)
+	if err != nil {
+		return err
+	}
+	if denied {
+		return NotAllowedError
+	}
+	if !p.restricted() || p.allow[email] {
+		return nil
+	}
+	if at := strings.LastIndex(email, "@"); at >= 0 && p.domains[email[at+1:]] {
+		return nil
+	}
+
+	invited, err := p.store.IsInvited(ctx, email)
+	if err != nil {
+		return err
+	}
+	if invited {
+		return nil
+	}
+
+	return NotAllowedError
+}
+
+// Admit checks an email at sign-in, redeeming inviteCode if the email isn't
+// otherwise allowed.
+func (p *AccessPolicy) Admit(ctx context.Context, email string, inviteCode string) error {
+	err := p.Check(ctx, email)
+	if err != NotAllowedError || len(inviteCode) == 0 || !p.inviteCodes {
+		return err
+	}
+	denied, err := p.denied(ctx, strings.ToLower(email))
+	if err != nil {
+		return err
+	}
+	if denied {
+		return NotAllowedError
+	}
+
+	redeemed, err := p.store.RedeemInvite(ctx, inviteCode, strings.ToLower(email))
+	if err != nil {
+		return err
+	}
+	if !redeemed {
+		return InvalidInviteError
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"testing"
+)
+
+type fakeAccessStore struct {
+	invited map[string]bool
+	denied  map[string]bool
+}
+
+func (s *fakeAccessStore) IsInvited(ctx context.Context, email string) (bool, error) {
+	return s.invited[email], nil
+}
+
+func (s *fakeAccessStore) IsDenied(ctx context.Context, email string) (bool, error) {
+	return s.denied[email], nil
+}
+
+func (s *fakeAccessStore) RedeemInvite(ctx context.Context, code string, email string) (bool, error) {
+	return code == "welcome", nil
+}
+
+func TestAccessPolicyCheck(t *testing.T) {
+	store := &fakeAccessStore{
+		invited: map[string]bool{"guest@example.com": true},
+		denied:  map[string]bool{"banned@u.northwestern.edu": true},
+	}
+
+	tests := []struct {
+		name   string
+		policy *AccessPolicy
+		email  string
+		err    error
+	}{
+		{name: "open", policy: NewAccessPolicy(nil, nil, nil, store, false), email: "anyone@example.com"},
+		{name: "denied in an open policy", policy: NewAccessPolicy(nil, nil, nil, store, false), email: "banned@u.northwestern.edu", err: NotAllowedError},
+		{name: "allowed domain", policy: NewAccessPolicy([]string{"u.northwestern.edu"}, nil, nil, store, false), email: "Someone@u.northwestern.edu"},
+		{name: "denied in an allowed domain", policy: NewAccessPolicy([]string{"u.northwestern.edu"}, nil, nil, store, false), email: "banned@u.northwestern.edu", err: NotAllowedError},
+		{name: "configured deny", policy: NewAccessPolicy([]string{"u.northwestern.edu"}, nil, []string{"someone@u.northwestern.edu"}, store, false), email: "someone@u.northwestern.edu", err: NotAllowedError},
+		{name: "allowed by an admin without invite codes", policy: NewAccessPolicy([]string{"u.northwestern.edu"}, nil, nil, store, false), email: "guest@example.com"},
+		{name: "outside the allowed domain", policy: NewAccessPolicy([]string{"u.northwestern.edu"}, nil, nil, store, false), email: "someone@example.com", err: NotAllowedError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.policy.Check(context.Background(), test.email); err != test.err {
+				t.Errorf("Check(%q) = %v, want %v", test.email, err, test.err)
+			}
+		})
+	}
+}
+
+func TestAccessPolicyAdmit(t *testing.T) {
+	store := &fakeAccessStore{denied: map[string]bool{"banned@example.com": true}}
+
+	if err := NewAccessPolicy(nil, nil, nil, store, true).Admit(context.Background(), "someone@example.com", "welcome"); err != nil {
+		t.Errorf("redeeming a valid code: %v", err)
+	}
+	if err := NewAccessPolicy(nil, nil, nil, store, true).Admit(context.Background(), "someone@example.com", "expired"); err != InvalidInviteError {
+		t.Errorf("redeeming an invalid code: %v, want %v", err, InvalidInviteError)
+	}
+	if err := NewAccessPolicy(nil, nil, nil, store, true).Admit(context.Background(), "banned@example.com", "welcome"); err != NotAllowedError {
+		t.Errorf("redeeming as a denied user: %v, want %v", err, NotAllowedError)
+	}
+	if err := NewAccessPolicy([]string{"u.northwestern.edu"}, nil, nil, store, false).Admit(context.Background(), "someone@example.com", "welcome"); err != NotAllowedError {
+		t.Errorf("redeeming with invite codes off: %v, want %v", err, NotAllowedError)
+	}
+}
@@ -0,0 +1,240 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+var (
+	SessionRevokedError      = errors.New("session has been revoked")
+	InvalidRefreshTokenError = errors.New("invalid refresh token")
+)
+
+// SessionStore persists sessions. A session is keyed by the jti of the latest
+// access token issued for it and holds a hash of its current refresh token;
+// both change every time the session is refreshed.
+type SessionStore interface {
+	CreateSession(ctx context.Context, jti string, email string, refreshHash string, expiresAt time.Time) error
+	// RotateSession swaps in a new jti and refresh token hash for the active
+	// session holding refreshHash, returning its email. Presenting a refresh
+	// token that has already been rotated out revokes the session.
+	RotateSession(ctx context.Context, refreshHash string, jti string, newRefreshHash string, expiresAt time.Time) (string, bool, error)
+	IsSessionActive(ctx context.Context, jti string) (bool, error)
+	RevokeSession(ctx context.Context, jti string) error
+	RevokeSessionByRefreshHash(ctx context.Context, refreshHash string) error
+	RevokeSessionsByEmail(ctx context.Context, email string) error
+	DeleteExpiredSessions(ctx context.Context) error
+}
+
+type cachedSession struct {
+	email   string
+	active  bool
+	expires time.Time
+}
+
+// Sessions issues short-lived access tokens alongside rotating refresh tokens
+// and checks access tokens against the session store. Lookups are cached for
+// cacheTTL, which bounds how long a session revoked by another server keeps
+// working here; revocations made through this instance apply immediately.
+type Sessions struct {
+	store           SessionStore
+	tokens          *AuthToken
+	refreshLifetime time.Duration
+	cacheTTL        time.Duration
+
+	mu    sync.Mutex
+	cache map[string]*cachedSession
+}
+
+func NewSessions(store SessionStore, tokens *AuthToken, refreshLifetime time.Duration, cacheTTL time.Duration) *Sessions {
+	return &Sessions{
+		store:           store,
+		tokens:          tokens,
+		refreshLifetime: refreshLifetime,
+		cacheTTL:        cacheTTL,
+		cache:           make(map[string]*cachedSession),
+	}
+}
+
+func (s *Sessions) AccessTokenLifetime() time.Duration {
+	return s.tokens.Lifetime()
+}
+
+func (s *Sessions) RefreshTokenLifetime() time.Duration {
+	return s.refreshLifetime
+}
+
+func newRefreshToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	refreshToken := base64.RawURLEncoding.EncodeToString(b)
+	return refreshToken, hashRefreshToken(refreshToken), nil
+}
+
+func hashRefreshToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// Create starts a session, returning its access and refresh tokens.
+func (s *Sessions) Create(ctx context.Context, email string) (string, string, error) {
+	accessToken, jti, err := s.tokens.NewToken(email)
+	if err != nil {
+		return "", "", err
+	}
+
+	refreshToken, refreshHash, err := newRefreshToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := s.store.CreateSession(ctx, jti, email, refreshHash, time.Now().Add(s.refreshLifetime)); err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh
+// token, invalidating the old pair.
+func (s *Sessions) Refresh(ctx context.Context, refreshToken string) (string, string, error) {
+	newRefreshToken, newRefreshHash, err := newRefreshToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	jti, err := newTokenId()
+	if err != nil {
+		return "", "", err
+	}
+
+	email, ok, err := s.store.RotateSession(ctx, hashRefreshToken(refreshToken), jti, newRefreshHash, time.Now().Add(s.refreshLifetime))
+	if err != nil {
+		return "", "", err
+	}
+	if !ok {
+		return "", "", InvalidRefreshTokenError
+	}
+
+	accessToken, err := s.tokens.signedToken(email, jti)
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessToken, newRefreshToken, nil
+}
+
+// Authenticate validates an access token and checks that its session hasn't
+// been revoked, returning the session's email.
+func (s *Sessions) Authenticate(ctx context.Context, accessToken string) (string, error) {
+	email, jti, err := s.tokens.ParseToken(accessToken)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	cached, ok := s.cache[jti]
+	s.mu.Unlock()
+	if !ok || now.After(cached.expires) {
+		active, err := s.store.IsSessionActive(ctx, jti)
+		if err != nil {
+			return "", err
+		}
+
+		cached = &cachedSession{email: email, active: active, expires: now.Add(s.cacheTTL)}
+		s.mu.Lock()
+		s.sweep(now)
+		s.cache[jti] = cached
+		s.mu.Unlock()
+	}
+
+	if !cached.active {
+		return "", SessionRevokedError
+	}
+
+	return email, nil
+}
+
+// SignOut revokes the session an access token or refresh token belongs to.
+// Either may be empty.
+func (s *Sessions) SignOut(ctx context.Context, accessToken string, refreshToken string) error {
+	if len(accessToken) > 0 {
+		if _, jti, err := s.tokens.ParseToken(accessToken); err == nil {
+			if err := s.store.RevokeSession(ctx, jti); err != nil {
+				return err
+			}
+
+			s.mu.Lock()
+			delete(s.cache, jti)
+			s.mu.Unlock()
+		}
+	}
+
+	if len(refreshToken) > 0 {
+		if err := s.store.RevokeSessionByRefreshHash(ctx, hashRefreshToken(refreshToken)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SignOutEverywhere revokes every session belonging to email.
+func (s *Sessions) SignOutEverywhere(ctx context.Context, email string) error {
+	if err := s.store.RevokeSessionsByEmail(ctx, email); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	for jti, cached := range s.cache {
+		if cached.email == email {
+			delete(s.cache, jti)
+		}
+	}
+	s.mu.Unlock()
+
+	return nil
+}
+
+const maxCachedSessions = 10000
+
+// sweep drops expired entries once the cache grows large. Callers must hold
+// s.mu.
+func (s *Sessions) sweep(now time.Time) {
+	if len(s.cache) < maxCachedSessions {
+		return
+	}
+
+	for jti, cached := range s.cache {
+		if now.After(cached.expires) {
+			delete(s.cache, jti)
+		}
+	}
+}
+
+// RunCleanup deletes expired sessions every interval until ctx is done.
+// Nothing else removes them, and a session is created every sign-in.
+func (s *Sessions) RunCleanup(ctx context.Context, interval time.Duration) {
+	for {
+		if err := s.store.DeleteExpiredSessions(ctx); err != nil {
+			log.Println(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/dgrijalva/jwt-go"
@@ -8,53 +10,105 @@ import (
 )
 
 type authClaims struct {
-	email string
+	Email string `json:"email"`
 	jwt.StandardClaims
 }
 
 type AuthToken struct {
-	secret        []byte
-	signingMethod jwt.SigningMethod
+	keys     *KeyRing
+	issuer   string
+	audience string
+	lifetime time.Duration
 }
 
-func NewAuth(secret string, alg string) *AuthToken {
-	return &AuthToken{secret: []byte(secret), signingMethod: jwt.GetSigningMethod(alg)}
+func NewAuth(keys *KeyRing, issuer string, audience string, lifetime time.Duration) *AuthToken {
+	return &AuthToken{keys: keys, issuer: issuer, audience: audience, lifetime: lifetime}
 }
 
-func (a *AuthToken) sign(email string) *jwt.Token {
-	exp := time.Now().Add(24 * time.Hour).Unix()
-	return jwt.NewWithClaims(a.signingMethod, authClaims{email, jwt.StandardClaims{ExpiresAt: exp}})
+func newTokenId() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
 }
 
-func (a *AuthToken) parseClaims(tokenString string) (*authClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &authClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
+func (a *AuthToken) newClaims(email string, jti string) *authClaims {
+	now := time.Now()
+	return &authClaims{
+		Email: email,
+		StandardClaims: jwt.StandardClaims{
+			Subject:   email,
+			Issuer:    a.issuer,
+			Audience:  a.audience,
+			Id:        jti,
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
+			ExpiresAt: now.Add(a.lifetime).Unix(),
+		},
+	}
+}
 
-		return a.secret, nil
-	})
+func (a *AuthToken) parseClaims(tokenString string) (*authClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &authClaims{}, a.keys.keyFunc)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*authClaims); ok && token.Valid {
-		return claims, nil
-	} else {
+	claims, ok := token.Claims.(*authClaims)
+	if !ok || !token.Valid {
 		return nil, errors.New("unexpected claims")
 	}
+
+	now := time.Now().Unix()
+	if !claims.VerifyExpiresAt(now, true) || !claims.VerifyIssuedAt(now, true) || !claims.VerifyNotBefore(now, true) {
+		return nil, errors.New("token is expired or not valid yet")
+	}
+	if !claims.VerifyIssuer(a.issuer, true) {
+		return nil, fmt.Errorf("unexpected issuer: %s", claims.Issuer)
+	}
+	if !claims.VerifyAudience(a.audience, true) {
+		return nil, fmt.Errorf("unexpected audience: %s", claims.Audience)
+	}
+	if len(claims.Id) == 0 {
+		return nil, errors.New("token has no id")
+	}
+	if len(claims.Email) == 0 || claims.Subject != claims.Email {
+		return nil, errors.New("token has no subject")
+	}
+
+	return claims, nil
 }
 
-func (a *AuthToken) TokenStringForUser(email string) (string, error) {
-	return a.sign(email).SignedString(a.secret)
+func (a *AuthToken) Lifetime() time.Duration {
+	return a.lifetime
 }
 
-func (a *AuthToken) UserFromTokenString(tokenString string) (string, error) {
+func (a *AuthToken) NewToken(email string) (string, string, error) {
+	jti, err := newTokenId()
+	if err != nil {
+		return "", "", err
+	}
+
+	tokenString, err := a.signedToken(email, jti)
+	if err != nil {
+		return "", "", err
+	}
+
+	return tokenString, jti, nil
+}
+
+func (a *AuthToken) signedToken(email string, jti string) (string, error) {
+	return a.keys.sign(a.newClaims(email, jti))
+}
+
+func (a *AuthToken) ParseToken(tokenString string) (string, string, error) {
 	claims, err := a.parseClaims(tokenString)
 	if err != nil {
-		return "", err
-	} else {
-		return claims.email, nil
+		return "", "", err
 	}
+
+	return claims.Email, claims.Id, nil
 }
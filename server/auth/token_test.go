@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func testKeyRing(t *testing.T, kid string) *KeyRing {
+	t.Helper()
+
+	key, err := NewHMACKey(kid, "HS256", []byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ring, err := NewKeyRing(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ring
+}
+
+func TestTokenRoundTrip(t *testing.T) {
+	a := NewAuth(testKeyRing(t, "current"), "northwestern", "northwestern", time.Minute)
+
+	tokenString, jti, err := a.NewToken("someone@u.northwestern.edu")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	email, parsedJti, err := a.ParseToken(tokenString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if email != "someone@u.northwestern.edu" {
+		t.Errorf("email = %q, want someone@u.northwestern.edu", email)
+	}
+	if parsedJti != jti {
+		t.Errorf("jti = %q, want %q", parsedJti, jti)
+	}
+
+	claims, err := a.parseClaims(tokenString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != email {
+		t.Errorf("sub = %q, want %q", claims.Subject, email)
+	}
+}
+
+func TestTokenRejected(t *testing.T) {
+	ring := testKeyRing(t, "current")
+	verifier := NewAuth(ring, "northwestern", "northwestern", time.Minute)
+
+	tests := []struct {
+		name   string
+		signer *AuthToken
+		jti    string
+	}{
+		{name: "wrong issuer", signer: NewAuth(ring, "someone-else", "northwestern", time.Minute), jti: "id"},
+		{name: "wrong audience", signer: NewAuth(ring, "northwestern", "someone-else", time.Minute), jti: "id"},
+		{name: "expired", signer: NewAuth(ring, "northwestern", "northwestern", -time.Minute), jti: "id"},
+		{name: "no jti", signer: verifier, jti: ""},
+		{name: "unknown kid", signer: NewAuth(testKeyRing(t, "retired"), "northwestern", "northwestern", time.Minute), jti: "id"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokenString, err := test.signer.signedToken("someone@u.northwestern.edu", test.jti)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, _, err := verifier.ParseToken(tokenString); err == nil {
+				t.Error("token was accepted")
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/andrewmthomas87/northwestern/apierror"
+	"github.com/andrewmthomas87/northwestern/database"
+	"github.com/gin-gonic/gin"
+	"github.com/vektah/gqlparser/gqlerror"
+	"log"
+	"net/http"
+)
+
+type requestIDKey struct{}
+
+const requestIDHeader = "X-Request-ID"
+
+// requestIDHandler tags every request with a random id, sent back in a header,
+// so errors a client sees can be matched with what the server logged.
+func requestIDHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			log.Println(err)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		id := hex.EncodeToString(b)
+
+		c.Header(requestIDHeader, id)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIDKey{}, id))
+	}
+}
+
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// presentError gives every error a code. Errors meant for clients keep their
+// message; anything else is logged and replaced, so database and driver errors
+// never reach clients.
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	switch e := err.(type) {
+	case *apierror.Error:
+		return graphql.DefaultErrorPresenter(ctx, e)
+	case *gqlerror.Error:
+		return graphql.DefaultErrorPresenter(ctx, e)
+	}
+	if err == sql.ErrNoRows {
+		return graphql.DefaultErrorPresenter(ctx, apierror.New(apierror.NotFound, "not found"))
+	}
+
+	id := requestID(ctx)
+	log.Printf("request %s: %v: %s", id, graphql.GetResolverContext(ctx).Path(), err)
+
+	apiErr := apierror.New(apierror.Internal, "internal error, reference request "+id)
+	if database.Unavailable(err) {
+		apiErr = apierror.New(apierror.UpstreamUnavailable, "temporarily unavailable, try again later; reference request "+id)
+	}
+
+	presented := graphql.DefaultErrorPresenter(ctx, apiErr)
+	presented.Extensions["requestId"] = id
+	return presented
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"github.com/andrewmthomas87/northwestern/server/auth"
+	"github.com/spf13/viper"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+type keyConfig struct {
+	Kid            string `mapstructure:"kid"`
+	Alg            string `mapstructure:"alg"`
+	Secret         string `mapstructure:"secret"`
+	PrivateKeyFile string `mapstructure:"privateKeyFile"`
+	SignFrom       string `mapstructure:"signFrom"`
+	SignUntil      string `mapstructure:"signUntil"`
+	VerifyUntil    string `mapstructure:"verifyUntil"`
+}
+
+func parseKeyTime(value string) (time.Time, error) {
+	if len(value) == 0 {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+func (c *keyConfig) signingKey() (*auth.SigningKey, error) {
+	var key *auth.SigningKey
+	var err error
+	if strings.HasPrefix(c.Alg, "HS") {
+		key, err = auth.NewHMACKey(c.Kid, c.Alg, []byte(c.Secret))
+	} else {
+		var pemData []byte
+		pemData, err = ioutil.ReadFile(c.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		key, err = auth.ParsePrivateKey(c.Kid, c.Alg, pemData)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if key.SignFrom, err = parseKeyTime(c.SignFrom); err != nil {
+		return nil, fmt.Errorf("key %s: %s", c.Kid, err)
+	}
+	if key.SignUntil, err = parseKeyTime(c.SignUntil); err != nil {
+		return nil, fmt.Errorf("key %s: %s", c.Kid, err)
+	}
+	if key.VerifyUntil, err = parseKeyTime(c.VerifyUntil); err != nil {
+		return nil, fmt.Errorf("key %s: %s", c.Kid, err)
+	}
+
+	return key, nil
+}
+
+const minSecretLength = 32
+
+var weakSecrets = map[string]bool{
+	"secret":   true,
+	"changeme": true,
+	"password": true,
+	"dev":      true,
+	"test":     true,
+}
+
+// weak reports whether an HMAC key's secret is too short or a well-known
+// placeholder.
+func (c *keyConfig) weak() bool {
+	return strings.HasPrefix(c.Alg, "HS") && (len(c.Secret) < minSecretLength || weakSecrets[strings.ToLower(c.Secret)])
+}
+
+// loadKeyConfigs reads auth.keys, falling back to a single HS256 key from
+// auth.secret for configs that predate key rotation.
+func loadKeyConfigs() ([]*keyConfig, error) {
+	var keyConfigs []*keyConfig
+	if err := viper.UnmarshalKey("auth.keys", &keyConfigs); err != nil {
+		return nil, err
+	}
+
+	if len(keyConfigs) == 0 {
+		keyConfigs = []*keyConfig{{Kid: "default", Alg: "HS256", Secret: viper.GetString("auth.secret")}}
+	}
+
+	return keyConfigs, nil
+}
+
+func loadKeyRing(keyConfigs []*keyConfig) (*auth.KeyRing, error) {
+	keys := make([]*auth.SigningKey, len(keyConfigs))
+	for i, keyConfig := range keyConfigs {
+		key, err := keyConfig.signingKey()
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+	}
+
+	keyRing, err := auth.NewKeyRing(keys...)
+	if err != nil {
+		return nil, err
+	}
+	if !keyRing.CanSign() {
+		return nil, fmt.Errorf("none of auth.keys can sign tokens right now")
+	}
+
+	return keyRing, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"github.com/andrewmthomas87/northwestern/server/auth"
+	"github.com/andrewmthomas87/northwestern/server/ratelimit"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type rateLimits struct {
+	signIn   ratelimit.Limit
+	query    ratelimit.Limit
+	mutation ratelimit.Limit
+}
+
+// loadRateLimit reads rateLimit.<key>. A perMinute of zero turns the limit off.
+func loadRateLimit(key string) ratelimit.Limit {
+	return ratelimit.PerMinute(viper.GetInt("rateLimit."+key+".perMinute"), viper.GetInt("rateLimit."+key+".burst"))
+}
+
+func loadRateLimits() *rateLimits {
+	return &rateLimits{
+		signIn:   loadRateLimit("signIn"),
+		query:    loadRateLimit("query"),
+		mutation: loadRateLimit("mutation"),
+	}
+}
+
+// rateLimitKey identifies who a request counts against: the API key if one
+// was used, then the signed in user, then the client's IP.
+func rateLimitKey(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); c.GetBool(apiKeyAuthKey) && strings.HasPrefix(header, bearerPrefix) {
+		return "key:" + auth.HashAPIKey(strings.TrimPrefix(header, bearerPrefix))
+	}
+	if email := c.GetString("email"); len(email) > 0 {
+		return "email:" + email
+	}
+
+	return "ip:" + c.ClientIP()
+}
+
+// spend takes n tokens from key's bucket, setting Retry-After on header if
+// there aren't enough. header may be nil. Unlimited limits always succeed.
+func spend(ctx context.Context, store ratelimit.Store, key string, header http.Header, limit ratelimit.Limit, n int) bool {
+	if limit.Unlimited() {
+		return true
+	}
+
+	ok, retryAfter, err := store.Take(ctx, key, limit, n)
+	if err != nil {
+		// A broken shared store shouldn't take the API down with it.
+		log.Println(err)
+		return true
+	}
+	if !ok && header != nil {
+		header.Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+
+	return ok
+}
+
+// charge takes n tokens from the caller's bucket for budget.
+func charge(c *gin.Context, store ratelimit.Store, budget string, limit ratelimit.Limit, n int) bool {
+	return spend(c.Request.Context(), store, budget+":"+rateLimitKey(c), c.Writer.Header(), limit, n)
+}
+
+func take(c *gin.Context, store ratelimit.Store, budget string, limit ratelimit.Limit) bool {
+	if charge(c, store, budget, limit, 1) {
+		return true
+	}
+
+	c.AbortWithStatusJSON(429, gin.H{"error": "rate limit exceeded"})
+	return false
+}
+
+func rateLimitHandler(store ratelimit.Store, budget string, limit ratelimit.Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		take(c, store, budget, limit)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"strings"
+)
+
+const (
+	modeDevelopment = "development"
+	modeProduction  = "production"
+)
+
+func serverMode() (string, error) {
+	mode := viper.GetString("server.mode")
+	switch mode {
+	case modeDevelopment, modeProduction:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown server.mode: %s", mode)
+	}
+}
+
+// checkProductionConfig refuses configs that are only safe for local
+// development.
+func checkProductionConfig(keyConfigs []*keyConfig) error {
+	var problems []string
+
+	if len(viper.GetStringSlice("dev.identities")) > 0 {
+		problems = append(problems, "dev.identities is set")
+	}
+	for _, keyConfig := range keyConfigs {
+		if keyConfig.weak() {
+			problems = append(problems, fmt.Sprintf("key %s has a weak secret (use at least %d random characters)", keyConfig.Kid, minSecretLength))
+		}
+	}
+	if !viper.GetBool("auth.cookie.secure") {
+		problems = append(problems, "auth.cookie.secure is off")
+	}
+
+	if len(problems) > 0 {
+		return errors.New("refusing to start in production mode: " + strings.Join(problems, "; "))
+	}
+
+	return nil
+}
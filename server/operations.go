@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/andrewmthomas87/northwestern/apierror"
+	"github.com/andrewmthomas87/northwestern/database"
+	"github.com/andrewmthomas87/northwestern/dataloader"
+	"github.com/andrewmthomas87/northwestern/querycost"
+	"github.com/andrewmthomas87/northwestern/server/persisted"
+	"github.com/andrewmthomas87/northwestern/server/ratelimit"
+	"github.com/gin-gonic/gin"
+	"github.com/vektah/gqlparser/ast"
+	"github.com/vektah/gqlparser/gqlerror"
+	"net/http"
+	"strings"
+)
+
+type callerKey struct{}
+
+// caller is what the GraphQL schema needs to know about the HTTP request an
+// operation came in on.
+type caller struct {
+	rateLimitKey string
+	// header is the response's, for Retry-After. Websocket responses have
+	// already been sent, so it's nil for them.
+	header    http.Header
+	websocket bool
+}
+
+// callerHandler passes the caller on to the GraphQL schema. The websocket test
+// is the one gqlgen uses to pick its transport.
+func callerHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		current := &caller{rateLimitKey: rateLimitKey(c), header: c.Writer.Header()}
+		if strings.Contains(c.GetHeader("Upgrade"), "websocket") {
+			current.header = nil
+			current.websocket = true
+		}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), callerKey{}, current))
+	}
+}
+
+func callerFor(ctx context.Context) *caller {
+	if current, ok := ctx.Value(callerKey{}).(*caller); ok {
+		return current
+	}
+	return &caller{}
+}
+
+// guardedSchema checks every operation before it runs, whichever transport
+// gqlgen took it in on: websockets may only subscribe, queries must be on the
+// persisted query allow-list, and the rate, depth and complexity limits apply.
+// By the time gqlgen calls the schema it has parsed and validated the operation,
+// so nothing here reads the request body again.
+type guardedSchema struct {
+	graphql.ExecutableSchema
+	db          *database.Database
+	queries     *persisted.Queries
+	store       ratelimit.Store
+	rateLimits  *rateLimits
+	queryLimits *queryLimits
+}
+
+func (s *guardedSchema) Query(ctx context.Context, op *ast.OperationDefinition) *graphql.Response {
+	if err := s.check(ctx, op); err != nil {
+		return errorResponse(ctx, err)
+	}
+
+	// Each request gets its own dataloaders, so lookups batch and cache within
+	// a request but never across them.
+	return s.ExecutableSchema.Query(dataloader.WithLoaders(ctx, dataloader.New(s.db)), op)
+}
+
+func (s *guardedSchema) Mutation(ctx context.Context, op *ast.OperationDefinition) *graphql.Response {
+	if err := s.check(ctx, op); err != nil {
+		return errorResponse(ctx, err)
+	}
+
+	return s.ExecutableSchema.Mutation(dataloader.WithLoaders(ctx, dataloader.New(s.db)), op)
+}
+
+func (s *guardedSchema) Subscription(ctx context.Context, op *ast.OperationDefinition) func() *graphql.Response {
+	if err := s.check(ctx, op); err != nil {
+		return graphql.OneShot(errorResponse(ctx, err))
+	}
+
+	return s.ExecutableSchema.Subscription(ctx, op)
+}
+
+func (s *guardedSchema) check(ctx context.Context, op *ast.OperationDefinition) error {
+	current := callerFor(ctx)
+	if current.websocket && op.Operation != ast.Subscription {
+		return apierror.New(apierror.InvalidArgument, "websocket connections may only subscribe; send queries and mutations over POST")
+	}
+
+	reqCtx := graphql.GetRequestContext(ctx)
+	if s.queries != nil && !s.queries.Allowed(ctx, reqCtx.RawQuery) {
+		return apierror.New(apierror.Forbidden, "query is not on the allow-list of persisted queries")
+	}
+
+	budget, limit := "query", s.rateLimits.query
+	if op.Operation == ast.Mutation {
+		budget, limit = "mutation", s.rateLimits.mutation
+	}
+	if !spend(ctx, s.store, budget+":"+current.rateLimitKey, current.header, limit, 1) {
+		return apierror.New(apierror.RateLimited, "rate limit exceeded")
+	}
+
+	analysis := querycost.Analyze(s.ExecutableSchema, op, reqCtx.Variables, s.queryLimits.fieldCosts)
+	if err := s.queryLimits.check(analysis); err != nil {
+		return err
+	}
+
+	perMinute := s.queryLimits.perMinute
+	if perMinute.Burst > 0 && !spend(ctx, s.store, "complexity:"+current.rateLimitKey, current.header, perMinute, analysis.Complexity) {
+		return apierror.Errorf(apierror.RateLimited, "complexity budget exceeded: operation has complexity %d of %d allowed per minute; most of it comes from %s", analysis.Complexity, perMinute.Burst, costliest(analysis))
+	}
+
+	return nil
+}
+
+func errorResponse(ctx context.Context, err error) *graphql.Response {
+	reqCtx := graphql.GetRequestContext(ctx)
+	return &graphql.Response{Errors: gqlerror.List{reqCtx.ErrorPresenter(ctx, err)}}
+}
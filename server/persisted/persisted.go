@@ -0,0 +1,134 @@
+package persisted
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	maxCached = 10000
+
+	// maxAddedLength is the longest query clients can add. Longer queries still
+	// run; they just have to be sent in full every time.
+	maxAddedLength = 64 * 1024
+)
+
+type Store interface {
+	InsertPersistedQuery(ctx context.Context, hash string, query string, registered bool) error
+	SelectPersistedQuery(ctx context.Context, hash string) (string, bool, error)
+	DeleteUnusedPersistedQueries(ctx context.Context, retention time.Duration) error
+}
+
+type entry struct {
+	query      string
+	registered bool
+}
+
+// Queries is gqlgen's persisted query cache, kept in memory in front of Store so
+// every server sees queries any of them has seen. In allow-list mode only
+// queries registered ahead of time are served and clients can't add more.
+type Queries struct {
+	store         Store
+	allowListOnly bool
+
+	mu    sync.RWMutex
+	cache map[string]entry
+}
+
+func NewQueries(store Store, allowListOnly bool) *Queries {
+	return &Queries{store: store, allowListOnly: allowListOnly, cache: make(map[string]entry)}
+}
+
+// Hash is the hash clients send for query under the automatic persisted query
+// protocol.
+func Hash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+func (q *Queries) remember(hash string, e entry) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.cache) >= maxCached {
+		// Evict something arbitrary; anything dropped is still in the store.
+		for hash := range q.cache {
+			delete(q.cache, hash)
+			break
+		}
+	}
+	q.cache[hash] = e
+}
+
+func (q *Queries) lookup(ctx context.Context, hash string) (entry, bool) {
+	q.mu.RLock()
+	e, ok := q.cache[hash]
+	q.mu.RUnlock()
+	if ok {
+		return e, true
+	}
+
+	query, registered, err := q.store.SelectPersistedQuery(ctx, hash)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Println(err)
+		}
+		return entry{}, false
+	}
+
+	e = entry{query: query, registered: registered}
+	q.remember(hash, e)
+	return e, true
+}
+
+func (q *Queries) Get(ctx context.Context, hash string) (string, bool) {
+	e, ok := q.lookup(ctx, hash)
+	if !ok || q.allowListOnly && !e.registered {
+		return "", false
+	}
+
+	return e.query, true
+}
+
+func (q *Queries) Add(ctx context.Context, hash string, query string) {
+	if q.allowListOnly || len(query) > maxAddedLength {
+		return
+	}
+
+	q.remember(hash, entry{query: query})
+	if err := q.store.InsertPersistedQuery(ctx, hash, query, false); err != nil {
+		log.Println(err)
+	}
+}
+
+// Allowed reports whether query may run. Outside allow-list mode anything may.
+func (q *Queries) Allowed(ctx context.Context, query string) bool {
+	if !q.allowListOnly {
+		return true
+	}
+
+	e, ok := q.lookup(ctx, Hash(query))
+	return ok && e.registered
+}
+
+// RunCleanup deletes queries clients added that haven't been used for
+// retention, every interval until ctx is done. A client whose query was
+// deleted adds it again on its next miss.
+func (q *Queries) RunCleanup(ctx context.Context, interval time.Duration, retention time.Duration) {
+	for {
+		if err := q.store.DeleteUnusedPersistedQueries(ctx, retention); err != nil {
+			log.Println(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
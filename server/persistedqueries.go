@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"github.com/andrewmthomas87/northwestern/database"
+	"github.com/andrewmthomas87/northwestern/server/persisted"
+	"github.com/spf13/viper"
+)
+
+const (
+	persistedQueriesOff       = "off"
+	persistedQueriesAutomatic = "automatic"
+	persistedQueriesAllowList = "allowList"
+)
+
+// loadPersistedQueries returns nil when persisted queries are turned off.
+func loadPersistedQueries(db *database.Database) (*persisted.Queries, error) {
+	switch mode := viper.GetString("graphql.persistedQueries"); mode {
+	case persistedQueriesOff:
+		return nil, nil
+	case persistedQueriesAutomatic:
+		return persisted.NewQueries(db, false), nil
+	case persistedQueriesAllowList:
+		return persisted.NewQueries(db, true), nil
+	default:
+		return nil, fmt.Errorf("graphql.persistedQueries must be %s, %s or %s, not %q", persistedQueriesOff, persistedQueriesAutomatic, persistedQueriesAllowList, mode)
+	}
+}
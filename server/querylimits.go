@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/andrewmthomas87/northwestern/apierror"
+	"github.com/andrewmthomas87/northwestern/querycost"
+	"github.com/andrewmthomas87/northwestern/server/ratelimit"
+	"github.com/spf13/viper"
+	"strings"
+)
+
+type fieldCostConfig struct {
+	Field string `mapstructure:"field"`
+	Cost  int    `mapstructure:"cost"`
+}
+
+// queryLimits bound what a single GraphQL operation may ask for. Zero turns a
+// limit off.
+type queryLimits struct {
+	maxDepth      int
+	maxComplexity int
+	perMinute     ratelimit.Limit
+	fieldCosts    querycost.FieldCosts
+}
+
+func loadQueryLimits(es graphql.ExecutableSchema) (*queryLimits, error) {
+	perMinute := viper.GetInt("graphql.complexityPerMinute")
+	limits := &queryLimits{
+		maxDepth:      viper.GetInt("graphql.maxDepth"),
+		maxComplexity: viper.GetInt("graphql.maxComplexity"),
+		perMinute:     ratelimit.PerMinute(perMinute, perMinute),
+		fieldCosts:    make(querycost.FieldCosts),
+	}
+	if perMinute > 0 && limits.maxComplexity > perMinute {
+		return nil, fmt.Errorf("graphql.maxComplexity (%d) is more than graphql.complexityPerMinute (%d)", limits.maxComplexity, perMinute)
+	}
+
+	var fieldCosts []fieldCostConfig
+	if err := viper.UnmarshalKey("graphql.fieldCosts", &fieldCosts); err != nil {
+		return nil, err
+	}
+	for _, fieldCost := range fieldCosts {
+		parts := strings.SplitN(fieldCost.Field, ".", 2)
+		if len(parts) != 2 || es.Schema().Types[parts[0]] == nil || es.Schema().Types[parts[0]].Fields.ForName(parts[1]) == nil {
+			return nil, fmt.Errorf("graphql.fieldCosts: unknown field %q", fieldCost.Field)
+		}
+		limits.fieldCosts[fieldCost.Field] = fieldCost.Cost
+	}
+
+	return limits, nil
+}
+
+// costliest says where an operation's complexity comes from for errors.
+func costliest(analysis *querycost.Analysis) string {
+	if len(analysis.CostliestAt) == 0 {
+		return "several top level fields"
+	}
+	return querycost.Path(analysis.CostliestAt)
+}
+
+func (l *queryLimits) check(analysis *querycost.Analysis) error {
+	if l.maxDepth > 0 && analysis.Depth > l.maxDepth {
+		return apierror.Errorf(apierror.QueryTooComplex, "operation is too deep: %s is at depth %d, the limit is %d", querycost.Path(analysis.DeepestAt), analysis.Depth, l.maxDepth)
+	}
+	if l.maxComplexity > 0 && analysis.Complexity > l.maxComplexity {
+		return apierror.Errorf(apierror.QueryTooComplex, "operation has complexity %d, the limit is %d; most of it comes from %s", analysis.Complexity, l.maxComplexity, costliest(analysis))
+	}
+
+	return nil
+}
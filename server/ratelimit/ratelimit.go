@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is a token bucket that refills at Rate tokens a second and holds at
+// most Burst tokens. A zero Rate turns the limit off.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0
+}
+
+func PerMinute(n int, burst int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: burst}
+}
+
+// Store keeps token buckets. Take removes n tokens from the bucket for key,
+// returning how long to wait before retrying if it holds fewer than that, and
+// always succeeds for unlimited limits. MemoryStore keeps buckets per process;
+// servers behind a load balancer can share limits by implementing Store on a
+// shared backend such as Redis.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, n int) (bool, time.Duration, error)
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time
+}
+
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, n int) (bool, time.Duration, error) {
+	if limit.Unlimited() {
+		return true, 0, nil
+	}
+
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		s.sweep(now)
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	} else {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*limit.Rate)
+		b.updated = now
+	}
+
+	if b.tokens >= float64(n) {
+		b.tokens -= float64(n)
+		b.full = now.Add(time.Duration((float64(limit.Burst) - b.tokens) / limit.Rate * float64(time.Second)))
+		return true, 0, nil
+	}
+
+	if n > limit.Burst {
+		return false, time.Hour, nil
+	}
+	return false, time.Duration((float64(n) - b.tokens) / limit.Rate * float64(time.Second)), nil
+}
+
+const maxBuckets = 100000
+
+// sweep drops buckets that have refilled completely, since a new bucket starts
+// out full anyway. Callers must hold s.mu.
+func (s *MemoryStore) sweep(now time.Time) {
+	if len(s.buckets) < maxBuckets {
+		return
+	}
+
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	refreshCookieSuffix = "_refresh"
+	csrfCookieSuffix    = "_csrf"
+	csrfHeader          = "X-CSRF-Token"
+
+	apiKeyAuthKey = "apiKey"
+)
+
+type cookieConfig struct {
+	name     string
+	domain   string
+	secure   bool
+	httpOnly bool
+	sameSite http.SameSite
+}
+
+func parseSameSite(value string) http.SameSite {
+	switch strings.ToLower(value) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+func (cc *cookieConfig) set(c *gin.Context, name string, value string, maxAge time.Duration, httpOnly bool) {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    url.QueryEscape(value),
+		Path:     "/",
+		Domain:   cc.domain,
+		Secure:   cc.secure,
+		HttpOnly: httpOnly,
+		SameSite: cc.sameSite,
+	}
+	if maxAge > 0 {
+		cookie.MaxAge = int(maxAge / time.Second)
+	} else {
+		cookie.MaxAge = -1
+	}
+
+	http.SetCookie(c.Writer, cookie)
+}
+
+// setSession stores a session's tokens along with a fresh CSRF token. The CSRF
+// cookie is never HttpOnly since the client has to echo it back in a header.
+func (cc *cookieConfig) setSession(c *gin.Context, accessToken string, accessLifetime time.Duration, refreshToken string, refreshLifetime time.Duration) error {
+	csrfToken, err := newCSRFToken()
+	if err != nil {
+		return err
+	}
+
+	cc.set(c, cc.name, accessToken, accessLifetime, cc.httpOnly)
+	cc.set(c, cc.name+refreshCookieSuffix, refreshToken, refreshLifetime, cc.httpOnly)
+	cc.set(c, cc.name+csrfCookieSuffix, csrfToken, refreshLifetime, false)
+
+	return nil
+}
+
+func (cc *cookieConfig) clearSession(c *gin.Context) {
+	cc.set(c, cc.name, "", 0, cc.httpOnly)
+	cc.set(c, cc.name+refreshCookieSuffix, "", 0, cc.httpOnly)
+	cc.set(c, cc.name+csrfCookieSuffix, "", 0, false)
+}
+
+func newCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func requestOrigin(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host
+}
+
+// csrfHandler protects cookie-authenticated, state-changing requests, which
+// includes every POST to /query and so every GraphQL mutation. A request must
+// come from this site or an allowed origin, and must echo the CSRF cookie in
+// the X-CSRF-Token header (double-submit). Requests authenticated with an API
+// key carry no ambient credentials and are exempt.
+func csrfHandler(cookies *cookieConfig, allowedOrigins map[string]bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isSafeMethod(c.Request.Method) || c.GetBool(apiKeyAuthKey) {
+			return
+		}
+
+		if !originAllowed(c, allowedOrigins) {
+			c.AbortWithStatusJSON(403, gin.H{"error": "origin not allowed"})
+			return
+		}
+
+		cookie, err := c.Cookie(cookies.name + csrfCookieSuffix)
+		header := c.GetHeader(csrfHeader)
+		if err != nil || len(cookie) == 0 || subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) != 1 {
+			c.AbortWithStatusJSON(403, gin.H{"error": "missing or invalid CSRF token"})
+			return
+		}
+	}
+}
+
+// websocketHandler only lets GET /query through to open a subscription
+// websocket. Browsers send cookies with websocket handshakes from any site and
+// don't apply CORS to them, so the origin is checked here instead.
+func websocketHandler(allowedOrigins map[string]bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+			c.AbortWithStatus(http.StatusMethodNotAllowed)
+			return
+		}
+
+		if !originAllowed(c, allowedOrigins) {
+			c.AbortWithStatusJSON(403, gin.H{"error": "origin not allowed"})
+		}
+	}
+}
+
+// signInCSRFHandler keeps other sites from signing a visitor into someone
+// else's account. There's no session to tie a CSRF token to yet, so sign-in
+// must come from an allowed origin and be JSON, which a cross-site form can't
+// send without a preflight.
+func signInCSRFHandler(allowedOrigins map[string]bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !originAllowed(c, allowedOrigins) {
+			c.AbortWithStatusJSON(403, gin.H{"error": "origin not allowed"})
+			return
+		}
+
+		if c.ContentType() != gin.MIMEJSON {
+			c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{"error": "sign-in requests must be application/json"})
+		}
+	}
+}
+
+// originAllowed reports whether a request's Origin, if it sent one, is this
+// site or an allowed origin.
+func originAllowed(c *gin.Context, allowedOrigins map[string]bool) bool {
+	origin := c.GetHeader("Origin")
+	return len(origin) == 0 || origin == requestOrigin(c.Request) || allowedOrigins[origin]
+}
+
+// corsHandler lets allowed origins make credentialed requests and answers
+// their preflight requests.
+func corsHandler(allowedOrigins map[string]bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if len(origin) == 0 {
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Origin")
+		if !allowedOrigins[origin] {
+			if c.Request.Method == http.MethodOptions {
+				c.AbortWithStatus(403)
+			}
+			return
+		}
+
+		c.Header("Access-Control-Allow-Origin", origin)
+		c.Header("Access-Control-Allow-Credentials", "true")
+
+		if c.Request.Method == http.MethodOptions {
+			c.Header("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, "+csrfHeader)
+			c.Header("Access-Control-Max-Age", "600")
+			c.AbortWithStatus(204)
+		}
+	}
+}
+
+func originSet(origins []string) map[string]bool {
+	set := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		set[strings.TrimSuffix(origin, "/")] = true
+	}
+
+	return set
+}
@@ -1,24 +1,45 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/handler"
 	"github.com/andrewmthomas87/northwestern"
+	"github.com/andrewmthomas87/northwestern/changes"
 	"github.com/andrewmthomas87/northwestern/database"
 	"github.com/andrewmthomas87/northwestern/generated"
+	"github.com/andrewmthomas87/northwestern/geo"
+	"github.com/andrewmthomas87/northwestern/models"
+	"github.com/andrewmthomas87/northwestern/notify"
+	"github.com/andrewmthomas87/northwestern/prerequisites"
+	"github.com/andrewmthomas87/northwestern/schedule"
 	"github.com/andrewmthomas87/northwestern/server/auth"
+	"github.com/andrewmthomas87/northwestern/server/persisted"
+	"github.com/andrewmthomas87/northwestern/server/ratelimit"
+	"github.com/andrewmthomas87/northwestern/webhooks"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
 	"log"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type signInData struct {
-	AccessCode string `json:"accessCode" binding:"required"`
+	IdToken    string `json:"idToken"`
+	AccessCode string `json:"accessCode"`
+	InviteCode string `json:"inviteCode"`
 }
 
-func signInHandler(cookieName string, googlePeople *auth.GooglePeople, auth *auth.AuthToken) gin.HandlerFunc {
+func abortForbidden(c *gin.Context, err error) {
+	c.AbortWithStatusJSON(403, gin.H{"error": err.Error()})
+}
+
+func signInHandler(cookies *cookieConfig, idTokenVerifier *auth.IDTokenVerifier, googlePeople *auth.GooglePeople, accessPolicy *auth.AccessPolicy, sessions *auth.Sessions) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var data signInData
 		err := c.BindJSON(&data)
@@ -26,61 +47,338 @@ func signInHandler(cookieName string, googlePeople *auth.GooglePeople, auth *aut
 			return
 		}
 
-		email, err := googlePeople.Me(data.AccessCode)
+		var email string
+		if len(data.IdToken) > 0 {
+			email, err = idTokenVerifier.Verify(c.Request.Context(), data.IdToken)
+		} else if len(data.AccessCode) > 0 && googlePeople != nil {
+			email, err = googlePeople.Me(data.AccessCode)
+		} else {
+			c.AbortWithStatus(400)
+			return
+		}
 		if err != nil {
 			c.AbortWithStatus(400)
 			return
 		}
 
-		tokenString, err := auth.TokenStringForUser(email)
+		if err := accessPolicy.Admit(c.Request.Context(), email, data.InviteCode); err != nil {
+			if err == auth.NotAllowedError || err == auth.InvalidInviteError {
+				abortForbidden(c, err)
+			} else {
+				c.AbortWithStatus(500)
+			}
+			return
+		}
+
+		accessToken, refreshToken, err := sessions.Create(c.Request.Context(), email)
 		if err != nil {
-			c.AbortWithStatus(400)
+			c.AbortWithStatus(500)
 			return
 		}
 
-		c.SetCookie(cookieName, tokenString, int(24*time.Hour/time.Second), "/", "", false, false)
+		if err := cookies.setSession(c, accessToken, sessions.AccessTokenLifetime(), refreshToken, sessions.RefreshTokenLifetime()); err != nil {
+			c.AbortWithStatus(500)
+			return
+		}
 	}
 }
 
-func devSignInHandler(cookieName string, auth *auth.AuthToken) gin.HandlerFunc {
+// devSignInHandler signs in as one of the configured development identities,
+// the first one unless another is picked with ?email=.
+func devSignInHandler(cookies *cookieConfig, sessions *auth.Sessions, identities []string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenString, err := auth.TokenStringForUser("andrewmthomas87@gmail.com")
+		email := identities[0]
+		if requested := c.Query("email"); len(requested) > 0 {
+			email = ""
+			for _, identity := range identities {
+				if identity == requested {
+					email = identity
+				}
+			}
+			if len(email) == 0 {
+				c.AbortWithStatusJSON(400, gin.H{"error": "unknown development identity", "identities": identities})
+				return
+			}
+		}
+
+		accessToken, refreshToken, err := sessions.Create(c.Request.Context(), email)
 		if err != nil {
 			c.AbortWithStatus(400)
 			return
 		}
 
-		c.SetCookie(cookieName, tokenString, int(24*time.Hour/time.Second), "/", "", false, false)
+		if err := cookies.setSession(c, accessToken, sessions.AccessTokenLifetime(), refreshToken, sessions.RefreshTokenLifetime()); err != nil {
+			c.AbortWithStatus(500)
+			return
+		}
+
 		c.Redirect(http.StatusTemporaryRedirect, "/")
 	}
 }
 
-func authHandler(cookieName string, auth *auth.AuthToken) gin.HandlerFunc {
+func refreshHandler(cookies *cookieConfig, sessions *auth.Sessions) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenString, err := c.Cookie(cookieName)
+		refreshToken, err := c.Cookie(cookies.name + refreshCookieSuffix)
 		if err != nil {
 			c.AbortWithStatus(401)
 			return
 		}
 
-		email, err := auth.UserFromTokenString(tokenString)
-		if err != nil {
+		accessToken, refreshToken, err := sessions.Refresh(c.Request.Context(), refreshToken)
+		if err == auth.InvalidRefreshTokenError {
+			cookies.clearSession(c)
 			c.AbortWithStatus(401)
 			return
+		} else if err != nil {
+			c.AbortWithStatus(500)
+			return
+		}
+
+		if err := cookies.setSession(c, accessToken, sessions.AccessTokenLifetime(), refreshToken, sessions.RefreshTokenLifetime()); err != nil {
+			c.AbortWithStatus(500)
+			return
+		}
+	}
+}
+
+func signOutHandler(cookies *cookieConfig, sessions *auth.Sessions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		accessToken, _ := c.Cookie(cookies.name)
+		refreshToken, _ := c.Cookie(cookies.name + refreshCookieSuffix)
+
+		if err := sessions.SignOut(c.Request.Context(), accessToken, refreshToken); err != nil {
+			c.AbortWithStatus(500)
+			return
+		}
+
+		cookies.clearSession(c)
+	}
+}
+
+// signOutEverywhereHandler ends every session the user has. API keys can't do
+// this, since even a read-only key could otherwise sign its owner out.
+func signOutEverywhereHandler(cookies *cookieConfig, sessions *auth.Sessions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetBool(apiKeyAuthKey) {
+			c.AbortWithStatusJSON(403, gin.H{"error": "API keys can't sign out sessions; sign in instead"})
+			return
+		}
+
+		if err := sessions.SignOutEverywhere(c.Request.Context(), c.GetString("email")); err != nil {
+			c.AbortWithStatus(500)
+			return
+		}
+
+		cookies.clearSession(c)
+	}
+}
+
+func jwksHandler(keyRing *auth.KeyRing) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(200, keyRing.JWKS())
+	}
+}
+
+const bearerPrefix = "Bearer "
+
+// authHandler accepts either a session cookie or an API key sent as
+// "Authorization: Bearer <key>".
+func authHandler(cookies *cookieConfig, accessPolicy *auth.AccessPolicy, sessions *auth.Sessions, apiKeys *auth.APIKeys) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var email string
+		var readOnly, usingAPIKey bool
+		if header := c.GetHeader("Authorization"); len(header) > 0 {
+			if !strings.HasPrefix(header, bearerPrefix) {
+				c.AbortWithStatus(401)
+				return
+			}
+
+			var err error
+			email, readOnly, err = apiKeys.Authenticate(c.Request.Context(), strings.TrimPrefix(header, bearerPrefix))
+			if err == auth.InvalidAPIKeyError {
+				c.AbortWithStatus(401)
+				return
+			} else if err != nil {
+				c.AbortWithStatus(500)
+				return
+			}
+			usingAPIKey = true
+		} else {
+			tokenString, err := c.Cookie(cookies.name)
+			if err != nil {
+				c.AbortWithStatus(401)
+				return
+			}
+
+			email, err = sessions.Authenticate(c.Request.Context(), tokenString)
+			if err != nil {
+				c.AbortWithStatus(401)
+				return
+			}
+		}
+
+		if err := accessPolicy.Check(c.Request.Context(), email); err != nil {
+			if err == auth.NotAllowedError {
+				abortForbidden(c, err)
+			} else {
+				c.AbortWithStatus(500)
+			}
+			return
+		}
+
+		ctx := auth.WithEmail(c.Request.Context(), email)
+		if usingAPIKey {
+			ctx = auth.WithAPIKey(ctx, readOnly)
 		}
 
 		c.Set("email", email)
+		c.Set(apiKeyAuthKey, usingAPIKey)
+		c.Request = c.Request.WithContext(ctx)
 	}
 }
 
-func graphqlHandler(db *database.Database) gin.HandlerFunc {
-	h := handler.GraphQL(generated.NewExecutableSchema(generated.Config{Resolvers: &northwestern.Resolver{Db: db}}))
+func newExecutableSchema(db *database.Database, broker *changes.Broker, dispatcher *webhooks.Dispatcher) graphql.ExecutableSchema {
+	resolver := &northwestern.Resolver{Db: db, Changes: broker, Webhooks: dispatcher}
+	return generated.NewExecutableSchema(generated.Config{
+		Resolvers:  resolver,
+		Directives: generated.DirectiveRoot{HasRole: resolver.HasRole},
+		Complexity: northwestern.NewComplexityRoot(),
+	})
+}
+
+func graphqlHandler(es graphql.ExecutableSchema, queries *persisted.Queries) gin.HandlerFunc {
+	options := []handler.Option{handler.ResolverMiddleware(northwestern.ReadOnlyMiddleware), handler.ErrorPresenter(presentError)}
+	if queries != nil {
+		options = append(options, handler.EnablePersistedQueryCache(queries))
+	}
+	h := handler.GraphQL(es, options...)
 
 	return func(c *gin.Context) {
 		h.ServeHTTP(c.Writer, c.Request)
 	}
 }
 
+func prerequisitesHandler(db *database.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := c.DefaultQuery("format", prerequisites.FormatJSON)
+		if format != prerequisites.FormatDOT && format != prerequisites.FormatJSON {
+			c.AbortWithStatus(400)
+			return
+		}
+
+		graph, err := prerequisites.Load(c.Request.Context(), db, c.Param("subject"))
+		if err == sql.ErrNoRows {
+			c.AbortWithStatus(404)
+			return
+		} else if err != nil {
+			c.AbortWithStatus(500)
+			return
+		}
+
+		if format == prerequisites.FormatDOT {
+			c.Header("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		} else {
+			c.Header("Content-Type", "application/json; charset=utf-8")
+		}
+		if err := graph.Write(c.Writer, format); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+type busyHour struct {
+	Hour     string `json:"hour"`
+	Meetings int    `json:"meetings"`
+}
+
+const busiestHoursCount = 3
+
+func buildingsGeoJSONHandler(db *database.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		buildings, err := db.SelectAllBuildings(ctx)
+		if err != nil {
+			c.AbortWithStatus(500)
+			return
+		}
+
+		rooms, err := db.SelectAllRooms(ctx)
+		if err != nil {
+			c.AbortWithStatus(500)
+			return
+		}
+
+		roomCounts := make(map[int]int)
+		roomBuildings := make(map[int]int, len(rooms))
+		for _, room := range rooms {
+			roomCounts[room.BuildingId]++
+			roomBuildings[room.Id] = room.BuildingId
+		}
+
+		var courses []*models.Course
+		if termString := c.Query("term"); len(termString) > 0 {
+			term, err := strconv.Atoi(termString)
+			if err != nil {
+				c.AbortWithStatus(400)
+				return
+			}
+
+			courses, err = db.SelectCourses(ctx, term, nil, nil)
+			if err != nil {
+				c.AbortWithStatus(500)
+				return
+			}
+		}
+
+		courseCounts := make(map[int]int)
+		meetings := make(map[int][]*schedule.Meeting)
+		for _, course := range courses {
+			building, ok := roomBuildings[course.Room]
+			if !ok {
+				continue
+			}
+			courseCounts[building]++
+			meetings[building] = append(meetings[building], schedule.Meetings(course)...)
+		}
+
+		collection := geo.NewFeatureCollection()
+		for _, building := range buildings {
+			properties := map[string]interface{}{
+				"name":  building.Name,
+				"rooms": roomCounts[building.Id],
+			}
+
+			if len(c.Query("term")) > 0 {
+				activity := schedule.HourlyActivity(meetings[building.Id])
+				busiestHours := make([]*busyHour, 0, len(activity))
+				for hour, count := range activity {
+					busiestHours = append(busiestHours, &busyHour{Hour: schedule.FormatClock(hour * 60), Meetings: count})
+				}
+				sort.Slice(busiestHours, func(i, j int) bool {
+					if busiestHours[i].Meetings != busiestHours[j].Meetings {
+						return busiestHours[i].Meetings > busiestHours[j].Meetings
+					}
+					return busiestHours[i].Hour < busiestHours[j].Hour
+				})
+				if len(busiestHours) > busiestHoursCount {
+					busiestHours = busiestHours[:busiestHoursCount]
+				}
+
+				properties["courses"] = courseCounts[building.Id]
+				properties["meetings"] = len(meetings[building.Id])
+				properties["busiestHours"] = busiestHours
+			}
+
+			collection.Features = append(collection.Features, geo.NewPointFeature(building.Id, building.Lat, building.Lon, properties))
+		}
+
+		c.Header("Content-Type", "application/geo+json")
+		c.JSON(200, collection)
+	}
+}
+
 func playgroundHandler() gin.HandlerFunc {
 	h := handler.Playground("GraphQL", "/query")
 
@@ -90,6 +388,32 @@ func playgroundHandler() gin.HandlerFunc {
 }
 
 func main() {
+	viper.SetDefault("server.mode", modeProduction)
+	viper.SetDefault("server.trustProxyHeaders", false)
+	viper.SetDefault("rateLimit.signIn.perMinute", 10)
+	viper.SetDefault("rateLimit.signIn.burst", 5)
+	viper.SetDefault("rateLimit.query.perMinute", 120)
+	viper.SetDefault("rateLimit.query.burst", 60)
+	viper.SetDefault("rateLimit.mutation.perMinute", 30)
+	viper.SetDefault("rateLimit.mutation.burst", 10)
+	viper.SetDefault("graphql.maxDepth", 12)
+	viper.SetDefault("graphql.maxComplexity", 50000)
+	viper.SetDefault("graphql.complexityPerMinute", 500000)
+	viper.SetDefault("graphql.persistedQueries", persistedQueriesAutomatic)
+	viper.SetDefault("graphql.persistedQueryRetention", 30*24*time.Hour)
+	viper.SetDefault("graphql.persistedQueryCleanupInterval", time.Hour)
+	viper.SetDefault("subscriptions.pollInterval", 5*time.Second)
+	viper.SetDefault("webhooks.timeout", 10*time.Second)
+	viper.SetDefault("auth.issuer", "northwestern")
+	viper.SetDefault("auth.audience", "northwestern")
+	viper.SetDefault("auth.accessTokenLifetime", 15*time.Minute)
+	viper.SetDefault("auth.refreshTokenLifetime", 30*24*time.Hour)
+	viper.SetDefault("auth.sessionCacheTTL", 30*time.Second)
+	viper.SetDefault("auth.sessionCleanupInterval", time.Hour)
+	viper.SetDefault("auth.cookie.secure", true)
+	viper.SetDefault("auth.cookie.httpOnly", true)
+	viper.SetDefault("auth.cookie.sameSite", "lax")
+
 	viper.SetConfigName("config")
 	viper.AddConfigPath(".")
 	if err := viper.ReadInConfig(); err != nil {
@@ -101,18 +425,100 @@ func main() {
 		log.Fatal(err)
 	}
 
-	googlePeople := auth.NewGooglePeople()
-	auth := auth.NewAuth(viper.GetString("auth.secret"), "HS256")
+	for _, email := range viper.GetStringSlice("auth.admins") {
+		if err := db.GrantRole(context.Background(), email, models.RoleAdmin); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	idTokenVerifier := auth.NewGoogleIDTokenVerifier(viper.GetStringSlice("auth.google.clientIds"))
+
+	var googlePeople *auth.GooglePeople
+	if viper.GetBool("auth.google.allowAccessCodeSignIn") {
+		googlePeople = auth.NewGooglePeople()
+	}
+	accessPolicy := auth.NewAccessPolicy(viper.GetStringSlice("auth.access.domains"), viper.GetStringSlice("auth.access.allow"), viper.GetStringSlice("auth.access.deny"), db, viper.GetBool("auth.access.inviteCodes"))
+
+	mode, err := serverMode()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	keyConfigs, err := loadKeyConfigs()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if mode == modeProduction {
+		if err := checkProductionConfig(keyConfigs); err != nil {
+			log.Fatal(err)
+		}
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	keyRing, err := loadKeyRing(keyConfigs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	authToken := auth.NewAuth(keyRing, viper.GetString("auth.issuer"), viper.GetString("auth.audience"), viper.GetDuration("auth.accessTokenLifetime"))
+	sessions := auth.NewSessions(db, authToken, viper.GetDuration("auth.refreshTokenLifetime"), viper.GetDuration("auth.sessionCacheTTL"))
+	go sessions.RunCleanup(context.Background(), viper.GetDuration("auth.sessionCleanupInterval"))
+	apiKeys := auth.NewAPIKeys(db)
+
+	cookies := &cookieConfig{
+		name:     viper.GetString("auth.cookieName"),
+		domain:   viper.GetString("auth.cookie.domain"),
+		secure:   viper.GetBool("auth.cookie.secure"),
+		httpOnly: viper.GetBool("auth.cookie.httpOnly"),
+		sameSite: parseSameSite(viper.GetString("auth.cookie.sameSite")),
+	}
+	allowedOrigins := originSet(viper.GetStringSlice("server.cors.allowedOrigins"))
+
+	limits := loadRateLimits()
+	limitStore := ratelimit.NewMemoryStore()
+
+	broker := changes.NewBroker()
+	go func() {
+		log.Fatal(changes.Poll(context.Background(), db, broker, viper.GetDuration("subscriptions.pollInterval")))
+	}()
+
+	dispatcher := webhooks.NewDispatcher(db, notify.NewPublicClient(viper.GetDuration("webhooks.timeout")))
+
+	es := newExecutableSchema(db, broker, dispatcher)
+	queryLimits, err := loadQueryLimits(es)
+	if err != nil {
+		log.Fatal(err)
+	}
+	persistedQueries, err := loadPersistedQueries(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if persistedQueries != nil {
+		go persistedQueries.RunCleanup(context.Background(), viper.GetDuration("graphql.persistedQueryCleanupInterval"), viper.GetDuration("graphql.persistedQueryRetention"))
+	}
+	guarded := &guardedSchema{ExecutableSchema: es, db: db, queries: persistedQueries, store: limitStore, rateLimits: limits, queryLimits: queryLimits}
 
 	router := gin.Default()
+	router.ForwardedByClientIP = viper.GetBool("server.trustProxyHeaders")
+	router.Use(requestIDHandler(), corsHandler(allowedOrigins))
 
-	router.POST("/sign-in", signInHandler(viper.GetString("auth.cookieName"), googlePeople, auth))
-	router.GET("/dev", devSignInHandler(viper.GetString("auth.cookieName"), auth))
+	router.POST("/sign-in", signInCSRFHandler(allowedOrigins), rateLimitHandler(limitStore, "signIn", limits.signIn), signInHandler(cookies, idTokenVerifier, googlePeople, accessPolicy, sessions))
+	router.POST("/refresh", csrfHandler(cookies, allowedOrigins), refreshHandler(cookies, sessions))
+	router.POST("/sign-out", csrfHandler(cookies, allowedOrigins), signOutHandler(cookies, sessions))
+	router.GET("/.well-known/jwks.json", jwksHandler(keyRing))
+	if identities := viper.GetStringSlice("dev.identities"); mode == modeDevelopment && len(identities) > 0 {
+		router.GET("/dev", devSignInHandler(cookies, sessions, identities))
+	}
 
 	authorized := router.Group("/")
-	authorized.Use(authHandler(viper.GetString("auth.cookieName"), auth))
+	authorized.Use(authHandler(cookies, accessPolicy, sessions, apiKeys), csrfHandler(cookies, allowedOrigins))
 
-	authorized.POST("/query", graphqlHandler(db))
+	authorized.POST("/sign-out-everywhere", signOutEverywhereHandler(cookies, sessions))
+	authorized.GET("/query", websocketHandler(allowedOrigins), callerHandler(), graphqlHandler(guarded, persistedQueries))
+	authorized.POST("/query", callerHandler(), graphqlHandler(guarded, persistedQueries))
+	authorized.GET("/prerequisites/:subject", prerequisitesHandler(db))
+	authorized.GET("/buildings.geojson", buildingsGeoJSONHandler(db))
 	authorized.GET("/", playgroundHandler())
 
 	log.Fatal(router.Run())
@@ -0,0 +1,83 @@
+package northwestern
+
+import (
+	"context"
+	"database/sql"
+	"github.com/andrewmthomas87/northwestern/changes"
+	"github.com/andrewmthomas87/northwestern/models"
+	"log"
+)
+
+type subscriptionResolver struct{ *Resolver }
+
+// latestCourse reads a course straight from the database. A subscription
+// outlives its request, so loaders would keep handing back the course as it was
+// the first time.
+func (r *subscriptionResolver) latestCourse(ctx context.Context, id int) (*models.Course, error) {
+	courses, err := r.Db.SelectCoursesByIds(ctx, []int{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(courses) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return courses[0], nil
+}
+
+func (r *subscriptionResolver) CourseUpdated(ctx context.Context, id int) (<-chan *models.Course, error) {
+	updates := r.Changes.Subscribe(ctx, func(change *models.CourseChange) bool {
+		return change.Course == id && change.Kind != models.CourseRemoved
+	})
+
+	courses := make(chan *models.Course, 1)
+	go func() {
+		defer close(courses)
+		for range updates {
+			course, err := r.latestCourse(ctx, id)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+
+			select {
+			case courses <- course:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return courses, nil
+}
+
+func (r *subscriptionResolver) SeatsChanged(ctx context.Context, term int, subjects []string) (<-chan *models.SeatChange, error) {
+	subjectSet := make(map[string]bool, len(subjects))
+	for _, subject := range subjects {
+		subjectSet[subject] = true
+	}
+
+	updates := r.Changes.Subscribe(ctx, func(change *models.CourseChange) bool {
+		return changes.SeatsChanged(change) && change.Term == term && (len(subjects) == 0 || subjectSet[change.Subject])
+	})
+
+	seatChanges := make(chan *models.SeatChange, 1)
+	go func() {
+		defer close(seatChanges)
+		for change := range updates {
+			course, err := r.latestCourse(ctx, change.Course)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+
+			select {
+			case seatChanges <- &models.SeatChange{Course: course, PreviousSeats: change.PreviousSeats, Seats: change.Seats}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return seatChanges, nil
+}
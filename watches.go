@@ -0,0 +1,83 @@
+package northwestern
+
+import (
+	"context"
+	"database/sql"
+	"github.com/andrewmthomas87/northwestern/apierror"
+	"github.com/andrewmthomas87/northwestern/models"
+	"github.com/andrewmthomas87/northwestern/server/auth"
+	"net/url"
+)
+
+var (
+	unknownCourseError     = apierror.New(apierror.NotFound, "no such course")
+	invalidWebhookURLError = apierror.New(apierror.InvalidArgument, "webhookUrl must be an https URL")
+)
+
+func (r *queryResolver) WatchedCourses(ctx context.Context) ([]*models.Course, error) {
+	email, ok := auth.EmailFromContext(ctx)
+	if !ok {
+		return nil, unauthenticatedError
+	}
+
+	return r.Db.SelectWatchedCourses(ctx, email)
+}
+
+func (r *queryResolver) NotificationPreferences(ctx context.Context) (*models.NotificationPreferences, error) {
+	email, ok := auth.EmailFromContext(ctx)
+	if !ok {
+		return nil, unauthenticatedError
+	}
+
+	return r.Db.SelectNotificationPreferences(ctx, email)
+}
+
+func (r *mutationResolver) WatchCourse(ctx context.Context, course int) (bool, error) {
+	email, ok := auth.EmailFromContext(ctx)
+	if !ok {
+		return false, unauthenticatedError
+	}
+
+	if _, err := r.loaders(ctx).Course(ctx, course); err == sql.ErrNoRows {
+		return false, unknownCourseError
+	} else if err != nil {
+		return false, err
+	}
+
+	if err := r.Db.InsertWatch(ctx, email, course); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *mutationResolver) UnwatchCourse(ctx context.Context, course int) (bool, error) {
+	email, ok := auth.EmailFromContext(ctx)
+	if !ok {
+		return false, unauthenticatedError
+	}
+
+	return r.Db.DeleteWatch(ctx, email, course)
+}
+
+func (r *mutationResolver) SetNotificationPreferences(ctx context.Context, channel models.NotificationChannel, frequency models.NotificationFrequency, webhookURL *string) (*models.NotificationPreferences, error) {
+	email, ok := auth.EmailFromContext(ctx)
+	if !ok {
+		return nil, unauthenticatedError
+	}
+
+	if webhookURL != nil {
+		if u, err := url.Parse(*webhookURL); err != nil || u.Scheme != "https" || len(u.Host) == 0 {
+			return nil, invalidWebhookURLError
+		}
+	} else if channel == models.NotificationChannelWebhook {
+		return nil, apierror.New(apierror.InvalidArgument, "webhookUrl is required for webhook notifications")
+	}
+
+	preferences := &models.NotificationPreferences{Channel: channel, Frequency: frequency, WebhookURL: webhookURL}
+	if err := r.Db.UpdateNotificationPreferences(ctx, email, preferences); err != nil {
+		return nil, err
+	}
+
+	return preferences, nil
+}
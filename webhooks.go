@@ -0,0 +1,87 @@
+package northwestern
+
+import (
+	"context"
+	"database/sql"
+	"github.com/andrewmthomas87/northwestern/apierror"
+	"github.com/andrewmthomas87/northwestern/models"
+	"github.com/andrewmthomas87/northwestern/server/auth"
+	"github.com/andrewmthomas87/northwestern/webhooks"
+	"net/url"
+)
+
+const defaultWebhookDeliveriesLimit = 50
+
+var (
+	unknownWebhookError         = apierror.New(apierror.NotFound, "no such webhook")
+	unknownWebhookDeliveryError = apierror.New(apierror.NotFound, "no such webhook delivery")
+)
+
+func (r *queryResolver) Webhooks(ctx context.Context) ([]*models.Webhook, error) {
+	return r.Db.SelectWebhooks(ctx)
+}
+
+func (r *queryResolver) WebhookDeliveries(ctx context.Context, webhook *int, status *models.WebhookDeliveryStatus, limit *int) ([]*models.WebhookDelivery, error) {
+	n, err := listLimit(limit, defaultWebhookDeliveriesLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Db.SelectWebhookDeliveries(ctx, webhook, status, n)
+}
+
+func (r *mutationResolver) CreateWebhook(ctx context.Context, webhookURL string, events []models.WebhookEvent, subjects []string) (*models.CreatedWebhook, error) {
+	email, ok := auth.EmailFromContext(ctx)
+	if !ok {
+		return nil, unauthenticatedError
+	}
+
+	if u, err := url.Parse(webhookURL); err != nil || u.Scheme != "https" || len(u.Host) == 0 {
+		return nil, invalidWebhookURLError
+	}
+	if len(events) == 0 {
+		return nil, apierror.New(apierror.InvalidArgument, "events must not be empty")
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	webhook, err := r.Db.InsertWebhook(ctx, webhookURL, secret, events, subjects, email)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedWebhook{Webhook: webhook, Secret: secret}, nil
+}
+
+func (r *mutationResolver) DeleteWebhook(ctx context.Context, id int) (bool, error) {
+	return r.Db.DeleteWebhook(ctx, id)
+}
+
+// TestWebhook sends a ping right away rather than waiting for the dispatcher,
+// so admins can see straight away whether the receiver accepts it.
+func (r *mutationResolver) TestWebhook(ctx context.Context, id int) (*models.WebhookDelivery, error) {
+	if _, err := r.Db.SelectWebhookEndpoint(ctx, id); err == sql.ErrNoRows {
+		return nil, unknownWebhookError
+	} else if err != nil {
+		return nil, err
+	}
+
+	delivery, err := r.Db.InsertWebhookDelivery(ctx, id, models.WebhookEventPing, `{"event":"PING","data":null}`)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Webhooks.Attempt(ctx, delivery)
+}
+
+func (r *mutationResolver) RetryWebhookDelivery(ctx context.Context, id int) (*models.WebhookDelivery, error) {
+	delivery, err := r.Db.RetryWebhookDelivery(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, unknownWebhookDeliveryError
+	}
+
+	return delivery, err
+}
@@ -0,0 +1,139 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/andrewmthomas87/northwestern/changes"
+	"github.com/andrewmthomas87/northwestern/models"
+)
+
+// Event is something webhooks can subscribe to. Subject is empty for events
+// that aren't about one subject, which reach every webhook listening for them.
+type Event struct {
+	Type    models.WebhookEvent
+	Subject string
+	Data    interface{}
+}
+
+type payload struct {
+	Event models.WebhookEvent `json:"event"`
+	Data  interface{}         `json:"data"`
+}
+
+func (e *Event) payload() (string, error) {
+	b, err := json.Marshal(&payload{Event: e.Type, Data: e.Data})
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+func TermEvents(terms []*models.Term) []*Event {
+	events := make([]*Event, len(terms))
+	for i, term := range terms {
+		events[i] = &Event{Type: models.WebhookEventTermPublished, Data: term}
+	}
+
+	return events
+}
+
+func hasField(change *models.CourseChange, field string) bool {
+	for _, f := range change.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// CourseEvents turns scraped course changes into events. An update can be
+// several events at once, such as a room change that is also a course update.
+func CourseEvents(courseChanges []*models.CourseChange) []*Event {
+	var events []*Event
+	add := func(eventType models.WebhookEvent, change *models.CourseChange) {
+		events = append(events, &Event{Type: eventType, Subject: change.Subject, Data: change})
+	}
+
+	for _, change := range courseChanges {
+		switch change.Kind {
+		case models.CourseAdded:
+			add(models.WebhookEventCourseAdded, change)
+		case models.CourseRemoved:
+			add(models.WebhookEventSectionCancelled, change)
+		case models.CourseUpdated:
+			add(models.WebhookEventCourseUpdated, change)
+			if hasField(change, "room") {
+				add(models.WebhookEventRoomChanged, change)
+			}
+			if changes.SeatsChanged(change) {
+				add(models.WebhookEventSeatsChanged, change)
+			}
+		}
+	}
+
+	return events
+}
+
+func matches(webhook *models.Webhook, event *Event) bool {
+	wanted := false
+	for _, eventType := range webhook.Events {
+		if eventType == event.Type {
+			wanted = true
+			break
+		}
+	}
+	if !wanted {
+		return false
+	}
+
+	if webhook.Subjects == nil || len(event.Subject) == 0 {
+		return true
+	}
+	for _, subject := range webhook.Subjects {
+		if subject == event.Subject {
+			return true
+		}
+	}
+	return false
+}
+
+type EnqueueStore interface {
+	SelectWebhooks(ctx context.Context) ([]*models.Webhook, error)
+	InsertWebhookDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error
+}
+
+// Enqueue queues a delivery of each event to every webhook whose filter it
+// passes.
+func Enqueue(ctx context.Context, store EnqueueStore, events []*Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	webhooks, err := store.SelectWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	var deliveries []*models.WebhookDelivery
+	for _, event := range events {
+		var body string
+		for _, webhook := range webhooks {
+			if !matches(webhook, event) {
+				continue
+			}
+
+			if len(body) == 0 {
+				if body, err = event.payload(); err != nil {
+					return err
+				}
+			}
+			deliveries = append(deliveries, &models.WebhookDelivery{WebhookID: webhook.ID, Event: event.Type, Payload: body})
+		}
+	}
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return store.InsertWebhookDeliveries(ctx, deliveries)
+}
@@ -0,0 +1,179 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/andrewmthomas87/northwestern/models"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+
+	maxAttempts     = 8
+	baseBackoff     = time.Minute
+	dispatchBatch   = 100
+	maxErrorLength  = 1000
+	maxResponseRead = 4096
+)
+
+var deletedWebhookError = errors.New("webhook deleted")
+
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Sign is the signature receivers should compute to check a delivery: the
+// hex HMAC-SHA256, keyed with the webhook's secret, of the timestamp header, a
+// dot and the body. Including the timestamp lets receivers reject replays.
+func Sign(secret string, timestamp string, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	io.WriteString(mac, timestamp)
+	io.WriteString(mac, ".")
+	io.WriteString(mac, body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff doubles the wait after each failed attempt: a minute, two, four and
+// so on.
+func backoff(attempts int) time.Duration {
+	return baseBackoff << uint(attempts-1)
+}
+
+type Store interface {
+	SelectWebhookEndpoint(ctx context.Context, id int) (*models.WebhookEndpoint, error)
+	SelectDueWebhookDeliveries(ctx context.Context, limit int) ([]*models.WebhookDelivery, error)
+	SelectWebhookDelivery(ctx context.Context, id int) (*models.WebhookDelivery, error)
+	UpdateWebhookDeliverySucceeded(ctx context.Context, id int, statusCode int) error
+	UpdateWebhookDeliveryFailed(ctx context.Context, id int, statusCode *int, message string, retryIn time.Duration, dead bool) error
+}
+
+// Dispatcher sends queued deliveries, retrying failures with backoff until they
+// land in the dead letter log. Only one dispatcher should run at a time.
+type Dispatcher struct {
+	store  Store
+	client *http.Client
+}
+
+func NewDispatcher(store Store, client *http.Client) *Dispatcher {
+	return &Dispatcher{store: store, client: client}
+}
+
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	for {
+		if err := d.Dispatch(ctx); err != nil {
+			log.Println(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Dispatch attempts every delivery that's due.
+func (d *Dispatcher) Dispatch(ctx context.Context) error {
+	for {
+		deliveries, err := d.store.SelectDueWebhookDeliveries(ctx, dispatchBatch)
+		if err != nil {
+			return err
+		}
+
+		for _, delivery := range deliveries {
+			if _, err := d.Attempt(ctx, delivery); err != nil {
+				return err
+			}
+		}
+
+		if len(deliveries) < dispatchBatch {
+			return nil
+		}
+	}
+}
+
+// Attempt sends a delivery once and records how it went, returning the
+// delivery as it is afterwards. Errors are only for failing to record it.
+func (d *Dispatcher) Attempt(ctx context.Context, delivery *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	var statusCode int
+	var sendErr error
+	endpoint, err := d.store.SelectWebhookEndpoint(ctx, delivery.WebhookID)
+	if err == sql.ErrNoRows {
+		sendErr = deletedWebhookError
+	} else if err != nil {
+		return nil, err
+	} else {
+		statusCode, sendErr = d.send(ctx, endpoint, delivery)
+	}
+
+	if sendErr == nil {
+		err = d.store.UpdateWebhookDeliverySucceeded(ctx, delivery.ID, statusCode)
+	} else {
+		var code *int
+		if statusCode > 0 {
+			code = &statusCode
+		}
+		message := sendErr.Error()
+		if len(message) > maxErrorLength {
+			message = message[:maxErrorLength]
+		}
+
+		attempts := delivery.Attempts + 1
+		err = d.store.UpdateWebhookDeliveryFailed(ctx, delivery.ID, code, message, backoff(attempts), attempts >= maxAttempts || sendErr == deletedWebhookError)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return d.store.SelectWebhookDelivery(ctx, delivery.ID)
+}
+
+func (d *Dispatcher) send(ctx context.Context, endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader([]byte(delivery.Payload)))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(delivery.Event))
+	req.Header.Set(DeliveryHeader, strconv.Itoa(delivery.ID))
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(endpoint.Secret, timestamp, delivery.Payload))
+
+	res, err := d.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(res.Body, maxResponseRead))
+		return res.StatusCode, fmt.Errorf("responded with %s: %s", res.Status, body)
+	}
+
+	return res.StatusCode, nil
+}